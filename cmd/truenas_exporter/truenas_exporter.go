@@ -0,0 +1,423 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"truenas_api/truenas_api"
+)
+
+// session maintains a logged in client, reconnecting when the connection drops.
+type session struct {
+	uri       string
+	verifySSL bool
+	user      string
+	pass      string
+	apiKey    string
+
+	mu       sync.Mutex
+	client   *truenas_api.Client
+	cancel   context.CancelFunc         // Stops the realtime stats subscription of client
+	realtime *truenas_api.RealtimeStats // Latest realtime stats snapshot
+	seenAt   time.Time                  // When realtime was received
+}
+
+// get returns the current client, connecting and logging in if needed.
+func (s *session) get() (*truenas_api.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	client, err := truenas_api.NewClient(s.uri, s.verifySSL)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Login(s.user, s.pass, s.apiKey); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	// Keep the latest realtime stats snapshot for scrapes
+	ctx, cancel := context.WithCancel(context.Background())
+	snapshots, err := truenas_api.NewReporting(client).Realtime(ctx, 0)
+	if err != nil {
+		cancel()
+		client.Close()
+		return nil, fmt.Errorf("failed to subscribe to realtime stats: %w", err)
+	}
+	go func() {
+		for stats := range snapshots {
+			s.mu.Lock()
+			if s.client == client {
+				s.realtime, s.seenAt = &stats, time.Now()
+			}
+			s.mu.Unlock()
+		}
+	}()
+
+	s.client, s.cancel = client, cancel
+	log.Printf("Connected to %s", s.uri)
+	return client, nil
+}
+
+// drop discards client if it is still the current one, so the next scrape reconnects.
+func (s *session) drop(client *truenas_api.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != client {
+		return
+	}
+	s.cancel()
+	client.Close()
+	s.client, s.realtime = nil, nil
+	log.Printf("Disconnected from %s, reconnecting on next scrape", s.uri)
+}
+
+// latestRealtime returns the latest realtime snapshot if it is fresher than maxAge.
+func (s *session) latestRealtime(maxAge time.Duration) *truenas_api.RealtimeStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.realtime == nil || time.Since(s.seenAt) > maxAge {
+		return nil
+	}
+	return s.realtime
+}
+
+// metricWriter writes metrics in the Prometheus text exposition format.
+type metricWriter struct {
+	w io.Writer
+}
+
+// family writes the HELP and TYPE lines of a metric.
+func (m *metricWriter) family(name, typ, help string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes a single sample; labels are given as name/value pairs.
+func (m *metricWriter) sample(name string, value float64, labels ...string) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	fmt.Fprintf(m.w, "%s %g\n", b.String(), value)
+}
+
+// escapeLabel escapes a label value for the text format.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// boolValue converts a bool to a gauge value.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// collector gathers one group of metrics.
+type collector struct {
+	name    string
+	collect func(ctx context.Context, client *truenas_api.Client, m *metricWriter) error
+}
+
+var collectors = []collector{
+	{"pools", collectPools},
+	{"datasets", collectDatasets},
+	{"disks", collectDiskTemperatures},
+	{"alerts", collectAlerts},
+	{"apps", collectApps},
+	{"replication", collectReplication},
+}
+
+func collectPools(ctx context.Context, client *truenas_api.Client, m *metricWriter) error {
+	pools, err := truenas_api.NewPools(client).Query(ctx, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	m.family("truenas_pool_healthy", "gauge", "Whether the pool is healthy.")
+	for _, pool := range pools {
+		m.sample("truenas_pool_healthy", boolValue(pool.Healthy), "pool", pool.Name, "status", pool.Status)
+	}
+	m.family("truenas_pool_size_bytes", "gauge", "Total size of the pool.")
+	for _, pool := range pools {
+		m.sample("truenas_pool_size_bytes", float64(pool.Size), "pool", pool.Name)
+	}
+	m.family("truenas_pool_allocated_bytes", "gauge", "Allocated space of the pool.")
+	for _, pool := range pools {
+		m.sample("truenas_pool_allocated_bytes", float64(pool.Allocated), "pool", pool.Name)
+	}
+	m.family("truenas_pool_free_bytes", "gauge", "Free space of the pool.")
+	for _, pool := range pools {
+		m.sample("truenas_pool_free_bytes", float64(pool.Free), "pool", pool.Name)
+	}
+	return nil
+}
+
+func collectDatasets(ctx context.Context, client *truenas_api.Client, m *metricWriter) error {
+	opts := &truenas_api.QueryOptions{Extra: map[string]interface{}{
+		"flat":              true,
+		"retrieve_children": false,
+		"properties":        []string{"used", "available"},
+	}}
+	datasets, err := truenas_api.NewPools(client).QueryDatasets(ctx, nil, opts)
+	if err != nil {
+		return err
+	}
+
+	m.family("truenas_dataset_used_bytes", "gauge", "Space used by the dataset and its children.")
+	for _, ds := range datasets {
+		if used, ok := ds.Used.Bytes(); ok {
+			m.sample("truenas_dataset_used_bytes", float64(used), "dataset", ds.Name, "pool", ds.Pool, "type", ds.Type)
+		}
+	}
+	m.family("truenas_dataset_available_bytes", "gauge", "Space available to the dataset.")
+	for _, ds := range datasets {
+		if available, ok := ds.Available.Bytes(); ok {
+			m.sample("truenas_dataset_available_bytes", float64(available), "dataset", ds.Name, "pool", ds.Pool, "type", ds.Type)
+		}
+	}
+	return nil
+}
+
+func collectDiskTemperatures(ctx context.Context, client *truenas_api.Client, m *metricWriter) error {
+	temperatures, err := truenas_api.NewDisks(client).Temperatures(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.family("truenas_disk_temperature_celsius", "gauge", "Disk temperature.")
+	for _, disk := range sortedKeys(temperatures) {
+		if temp := temperatures[disk]; temp != nil {
+			m.sample("truenas_disk_temperature_celsius", *temp, "disk", disk)
+		}
+	}
+	return nil
+}
+
+func collectAlerts(ctx context.Context, client *truenas_api.Client, m *metricWriter) error {
+	alerts, err := truenas_api.NewAlerts(client).List(ctx)
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, alert := range alerts {
+		if !alert.Dismissed {
+			counts[alert.Level]++
+		}
+	}
+	m.family("truenas_alerts", "gauge", "Number of active, undismissed alerts by level.")
+	for _, level := range []string{
+		truenas_api.AlertLevelInfo, truenas_api.AlertLevelNotice, truenas_api.AlertLevelWarning,
+		truenas_api.AlertLevelError, truenas_api.AlertLevelCritical, truenas_api.AlertLevelAlert,
+		truenas_api.AlertLevelEmergency,
+	} {
+		m.sample("truenas_alerts", float64(counts[level]), "level", level)
+	}
+	return nil
+}
+
+func collectApps(ctx context.Context, client *truenas_api.Client, m *metricWriter) error {
+	apps, err := truenas_api.NewApps(client).Query(ctx, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	m.family("truenas_app_state", "gauge", "State of the app, 1 for the current state.")
+	for _, app := range apps {
+		m.sample("truenas_app_state", 1, "app", app.Name, "state", app.State)
+	}
+	m.family("truenas_app_upgrade_available", "gauge", "Whether a newer version of the app is available.")
+	for _, app := range apps {
+		m.sample("truenas_app_upgrade_available", boolValue(app.UpgradeAvailable), "app", app.Name)
+	}
+	return nil
+}
+
+func collectReplication(ctx context.Context, client *truenas_api.Client, m *metricWriter) error {
+	tasks, err := truenas_api.NewReplications(client).Query(ctx, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	m.family("truenas_replication_state", "gauge", "State of the last run of the replication task, 1 for the current state.")
+	for _, task := range tasks {
+		m.sample("truenas_replication_state", 1, "replication", task.Name, "state", task.State.State)
+	}
+	m.family("truenas_replication_last_run_success", "gauge", "Whether the last run of the replication task succeeded.")
+	for _, task := range tasks {
+		m.sample("truenas_replication_last_run_success", boolValue(task.State.State == "SUCCESS"), "replication", task.Name)
+	}
+	m.family("truenas_replication_last_run_timestamp_seconds", "gauge", "Time of the last run of the replication task.")
+	for _, task := range tasks {
+		if task.State.Datetime != nil {
+			m.sample("truenas_replication_last_run_timestamp_seconds", float64(task.State.Datetime.Unix()), "replication", task.Name)
+		}
+	}
+	return nil
+}
+
+// writeRealtime writes the latest realtime stats snapshot.
+func writeRealtime(stats *truenas_api.RealtimeStats, m *metricWriter) {
+	m.family("truenas_cpu_usage_percent", "gauge", "CPU usage.")
+	for _, cpu := range sortedKeys(stats.CPU) {
+		m.sample("truenas_cpu_usage_percent", stats.CPU[cpu].Usage, "cpu", cpu)
+	}
+	m.family("truenas_cpu_temperature_celsius", "gauge", "CPU temperature.")
+	for _, cpu := range sortedKeys(stats.CPU) {
+		if temp := stats.CPU[cpu].TemperatureCelsius; temp != nil {
+			m.sample("truenas_cpu_temperature_celsius", *temp, "cpu", cpu)
+		}
+	}
+
+	m.family("truenas_memory_total_bytes", "gauge", "Physical memory.")
+	m.sample("truenas_memory_total_bytes", float64(stats.Memory.PhysicalMemoryTotal))
+	m.family("truenas_memory_available_bytes", "gauge", "Available physical memory.")
+	m.sample("truenas_memory_available_bytes", float64(stats.Memory.PhysicalMemoryAvailable))
+	m.family("truenas_zfs_arc_size_bytes", "gauge", "Size of the ZFS ARC.")
+	m.sample("truenas_zfs_arc_size_bytes", float64(stats.ZFS.ARCSize))
+	m.family("truenas_zfs_arc_max_size_bytes", "gauge", "Maximum size of the ZFS ARC.")
+	m.sample("truenas_zfs_arc_max_size_bytes", float64(stats.ZFS.ARCMaxSize))
+	m.family("truenas_zfs_arc_hit_ratio", "gauge", "ZFS ARC cache hit ratio.")
+	m.sample("truenas_zfs_arc_hit_ratio", stats.ZFS.CacheHitRatio)
+
+	m.family("truenas_disk_busy_percent", "gauge", "Aggregated busy time of all disks.")
+	m.sample("truenas_disk_busy_percent", stats.Disks.Busy)
+	m.family("truenas_disk_read_bytes_rate", "gauge", "Aggregated read rate of all disks.")
+	m.sample("truenas_disk_read_bytes_rate", stats.Disks.ReadBytes)
+	m.family("truenas_disk_write_bytes_rate", "gauge", "Aggregated write rate of all disks.")
+	m.sample("truenas_disk_write_bytes_rate", stats.Disks.WriteBytes)
+
+	m.family("truenas_interface_up", "gauge", "Whether the link of the network interface is up.")
+	for _, name := range sortedKeys(stats.Interfaces) {
+		m.sample("truenas_interface_up", boolValue(stats.Interfaces[name].LinkState == "LINK_STATE_UP"), "interface", name)
+	}
+	m.family("truenas_interface_received_bytes_rate", "gauge", "Receive rate of the network interface.")
+	for _, name := range sortedKeys(stats.Interfaces) {
+		m.sample("truenas_interface_received_bytes_rate", stats.Interfaces[name].ReceivedBytesRate, "interface", name)
+	}
+	m.family("truenas_interface_sent_bytes_rate", "gauge", "Send rate of the network interface.")
+	for _, name := range sortedKeys(stats.Interfaces) {
+		m.sample("truenas_interface_sent_bytes_rate", stats.Interfaces[name].SentBytesRate, "interface", name)
+	}
+}
+
+// sortedKeys returns the keys of a map in order, for stable output.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// connectionLost reports whether err means the session should be dropped: the
+// connection closed, or a call timed out, which a hung connection does on every call.
+func connectionLost(err error) bool {
+	return errors.Is(err, truenas_api.ErrClosed) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// metricsHandler serves all metrics, collected on each scrape.
+func metricsHandler(s *session, timeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		m := &metricWriter{w: &buf}
+		start := time.Now()
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		// The API is up if a call round-trips, not merely if the connection is open
+		client, err := s.get()
+		if err == nil {
+			if _, err = truenas_api.NewSystem(client).Info(ctx); err != nil && connectionLost(err) {
+				s.drop(client)
+			}
+		}
+		m.family("truenas_up", "gauge", "Whether the TrueNAS API could be reached.")
+		m.sample("truenas_up", boolValue(err == nil))
+		if err != nil {
+			log.Printf("Failed to reach the API: %v", err)
+		} else {
+			results := map[string]bool{}
+			for _, c := range collectors {
+				var section bytes.Buffer
+				err := c.collect(ctx, client, &metricWriter{w: &section})
+				results[c.name] = err == nil
+				if err != nil {
+					log.Printf("Collector %s failed: %v", c.name, err)
+					if connectionLost(err) {
+						s.drop(client)
+						break
+					}
+					continue
+				}
+				buf.Write(section.Bytes()) // Only emit complete sections
+			}
+
+			m.family("truenas_exporter_collector_success", "gauge", "Whether the collector succeeded.")
+			for _, c := range collectors {
+				m.sample("truenas_exporter_collector_success", boolValue(results[c.name]), "collector", c.name)
+			}
+			if stats := s.latestRealtime(time.Minute); stats != nil {
+				writeRealtime(stats, m)
+			}
+		}
+
+		m.family("truenas_exporter_scrape_duration_seconds", "gauge", "Time taken to collect the metrics.")
+		m.sample("truenas_exporter_scrape_duration_seconds", time.Since(start).Seconds())
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(buf.Bytes())
+	}
+}
+
+func main() {
+	serverURL := flag.String("uri", "", "WebSocket server URI (e.g., ws://localhost/api/current)")
+	verifySSL := flag.Bool("verifyssl", true, "Verify SSL certificates for wss:// connections")
+	user := flag.String("U", "", "Username for login")
+	pass := flag.String("P", "", "Password for login")
+	apiKey := flag.String("api-key", os.Getenv("TRUENAS_API_KEY"), "API key for login (defaults to $TRUENAS_API_KEY)")
+	listen := flag.String("listen", ":9814", "Address to serve /metrics on")
+	timeout := flag.Int("timeout", 20, "Timeout in seconds for collecting metrics")
+	flag.Parse()
+
+	if *serverURL == "" {
+		fmt.Println("Error: --uri must be provided.")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	s := &session{uri: *serverURL, verifySSL: *verifySSL, user: *user, pass: *pass, apiKey: *apiKey}
+	if _, err := s.get(); err != nil {
+		log.Printf("Initial connection failed, retrying on scrape: %v", err)
+	}
+
+	http.Handle("/metrics", metricsHandler(s, time.Duration(*timeout)*time.Second))
+	log.Printf("Serving metrics on %s/metrics", *listen)
+	log.Fatal(http.ListenAndServe(*listen, nil))
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"truenas_api/truenas_api"
+)
+
+// initialisms are name parts written in upper case, following Go naming conventions.
+var initialisms = map[string]bool{
+	"ACL": true, "ACME": true, "AD": true, "API": true, "CA": true, "CPU": true, "CSR": true,
+	"DNS": true, "GID": true, "GPU": true, "GUID": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"IPV4": true, "IPV6": true, "ISCSI": true, "JSON": true, "KDC": true, "LDAP": true, "LUN": true,
+	"MAC": true, "MTU": true, "NFS": true, "NTP": true, "OS": true, "PID": true, "SID": true,
+	"SMB": true, "SMART": true, "SSH": true, "SSL": true, "TCP": true, "TLS": true, "TTL": true,
+	"UDP": true, "UI": true, "UID": true, "UPS": true, "URI": true, "URL": true, "UTC": true,
+	"UUID": true, "VM": true, "ZFS": true,
+}
+
+// exportName converts a schema name such as "pool.dataset" or "get_instance"
+// into an exported Go identifier such as "PoolDataset" or "GetInstance".
+func exportName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, part := range parts {
+		if upper := strings.ToUpper(part); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	id := b.String()
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "X" + id
+	}
+	return id
+}
+
+// paramName converts a schema name into an unexported Go identifier for a
+// parameter, avoiding keywords and the names used by the generated code.
+func paramName(name string) string {
+	runes := []rune(exportName(name))
+	// Lower the leading upper case letters, except the start of the next word: "IDMap" -> "idMap"
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) && unicode.IsLower(runes[n]) {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	id := string(runes)
+	switch {
+	case token.IsKeyword(id), id == "ctx", id == "callback", id == "s", id == "result", id == "err":
+		return id + "Arg"
+	}
+	return id
+}
+
+// firstLine returns the first line of a description, for use in a comment.
+func firstLine(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = strings.TrimSpace(text[:i])
+	}
+	return text
+}
+
+// file collects the declarations of one generated file.
+type file struct {
+	decls bytes.Buffer
+}
+
+// generator turns method schemas into Go declarations.
+type generator struct {
+	version string
+	types   map[string]string              // Declared type names and their bodies, to reuse identical types
+	pending map[string]bool                // Struct types being generated, for recursive schemas
+	defs    map[string]*truenas_api.Schema // $defs of the method being generated
+	out     *file                          // File the current declarations are written to
+}
+
+// declare returns the name of a type declaration with body, declaring it in
+// the current file under name or, if name is taken by a different type, under
+// a numbered variant of name.
+func (g *generator) declare(name, description, body string) string {
+	candidate := name
+	for i := 2; ; i++ {
+		existing, taken := g.types[candidate]
+		if !taken {
+			break
+		}
+		if existing == body {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	g.types[candidate] = body
+	fmt.Fprintf(&g.out.decls, "// %s is generated from a JSON schema.\n", candidate)
+	if description != "" {
+		fmt.Fprintf(&g.out.decls, "//\n// %s\n", description)
+	}
+	fmt.Fprintf(&g.out.decls, "type %s %s\n\n", candidate, body)
+	return candidate
+}
+
+// goType returns the Go type for s, declaring struct types named after name as needed.
+func (g *generator) goType(s *truenas_api.Schema, name string) string {
+	if s == nil || s.Never {
+		return "interface{}"
+	}
+	if s.Ref != "" {
+		def, ok := g.defs[s.RefName()]
+		if !ok {
+			return "interface{}"
+		}
+		return g.goType(def, exportName(s.RefName()))
+	}
+	for _, variants := range [][]*truenas_api.Schema{s.AnyOf, s.OneOf} {
+		if len(variants) == 0 {
+			continue
+		}
+		var nonNull []*truenas_api.Schema
+		for _, v := range variants {
+			if len(v.Type) != 1 || v.Type[0] != "null" {
+				nonNull = append(nonNull, v)
+			}
+		}
+		if len(nonNull) != 1 {
+			return "interface{}"
+		}
+		t := g.goType(nonNull[0], name)
+		if len(nonNull) < len(variants) {
+			return nullable(t)
+		}
+		return t
+	}
+	if len(s.AllOf) == 1 {
+		return g.goType(s.AllOf[0], name)
+	}
+
+	var types []string
+	for _, t := range s.Type {
+		if t != "null" {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 && len(s.Properties) > 0 {
+		types = []string{"object"}
+	}
+	if len(types) != 1 {
+		return "interface{}"
+	}
+
+	var t string
+	switch types[0] {
+	case "string":
+		t = "string"
+	case "integer":
+		t = "int64"
+	case "number":
+		t = "float64"
+	case "boolean":
+		t = "bool"
+	case "array":
+		if s.Items != nil {
+			t = "[]" + g.goType(s.Items, name+"Item")
+		} else {
+			t = "[]interface{}"
+		}
+	case "object":
+		switch {
+		case len(s.Properties) > 0:
+			t = g.structType(s, name)
+		case s.AdditionalProperties != nil && !s.AdditionalProperties.Never:
+			t = "map[string]" + g.goType(s.AdditionalProperties, name+"Value")
+		default:
+			t = "map[string]interface{}"
+		}
+	default:
+		return "interface{}"
+	}
+	if s.Type.Has("null") {
+		return nullable(t)
+	}
+	return t
+}
+
+// nullable returns the type for a value of type t that may be null.
+func nullable(t string) string {
+	if strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[") || strings.HasPrefix(t, "*") || t == "interface{}" {
+		return t
+	}
+	return "*" + t
+}
+
+// structType declares a struct for an object schema and returns its name.
+func (g *generator) structType(s *truenas_api.Schema, name string) string {
+	if g.pending[name] {
+		return name
+	}
+	g.pending[name] = true
+	defer delete(g.pending, name)
+
+	required := make(map[string]bool)
+	for _, prop := range s.Required {
+		required[prop] = true
+	}
+	props := make([]string, 0, len(s.Properties))
+	for prop := range s.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+
+	var body strings.Builder
+	body.WriteString("struct {\n")
+	fields := make(map[string]bool)
+	for _, prop := range props {
+		field := exportName(prop)
+		for fields[field] {
+			field += "_"
+		}
+		fields[field] = true
+
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+		}
+		propSchema := s.Properties[prop]
+		t := g.goType(propSchema, name+field)
+		if !required[prop] && g.isStruct(t) {
+			t = "*" + t // Omit unset optional objects
+		}
+		fmt.Fprintf(&body, "\t%s %s `json:%q`", field, t, tag)
+		if desc := firstLine(propSchema.Description); desc != "" {
+			fmt.Fprintf(&body, " // %s", desc)
+		}
+		body.WriteString("\n")
+	}
+	body.WriteString("}")
+
+	return g.declare(name, firstLine(s.Description), body.String())
+}
+
+// isStruct reports whether t is a struct type declared by g.
+func (g *generator) isStruct(t string) bool {
+	return g.pending[t] || strings.HasPrefix(g.types[t], "struct")
+}
+
+// collectDefs adds the $defs of s and of its nested schemas to g.defs.
+func (g *generator) collectDefs(s *truenas_api.Schema) {
+	if s == nil {
+		return
+	}
+	for name, def := range s.Defs {
+		g.defs[name] = def
+		g.collectDefs(def)
+	}
+}
+
+// namespace writes the service type and method wrappers of one namespace.
+func (g *generator) namespace(ns string, names []string, methods map[string]truenas_api.MethodSchema) {
+	service := exportName(ns)
+	fmt.Fprintf(&g.out.decls, "// %s calls the %s.* methods.\n", service, ns)
+	fmt.Fprintf(&g.out.decls, "type %s struct {\n\tclient *truenas_api.Client // Reference to the WebSocket client\n}\n\n", service)
+	fmt.Fprintf(&g.out.decls, "// New%s creates a new %s service.\n", service, service)
+	fmt.Fprintf(&g.out.decls, "func New%s(client *truenas_api.Client) *%s {\n\treturn &%s{client: client}\n}\n\n", service, service, service)
+
+	for _, name := range names {
+		g.method(service, name, methods[name])
+	}
+}
+
+// method writes the wrapper of one method.
+func (g *generator) method(service, name string, m truenas_api.MethodSchema) {
+	g.defs = make(map[string]*truenas_api.Schema)
+	for _, s := range append(append([]*truenas_api.Schema{}, m.Accepts...), m.Returns...) {
+		g.collectDefs(s)
+	}
+	methodName := exportName(name[strings.LastIndex(name, ".")+1:])
+	typePrefix := service + methodName
+
+	var params, args []string
+	seen := make(map[string]bool)
+	for i, s := range m.Accepts {
+		arg := s.ParamName()
+		if arg == "" {
+			arg = fmt.Sprintf("arg%d", i)
+		}
+		id := paramName(arg)
+		for seen[id] {
+			id += "_"
+		}
+		seen[id] = true
+		params = append(params, fmt.Sprintf("%s %s", id, g.goType(s, typePrefix+exportName(arg))))
+		args = append(args, id)
+	}
+
+	var result string
+	if len(m.Returns) > 0 && !m.Job && !(len(m.Returns[0].Type) == 1 && m.Returns[0].Type[0] == "null") {
+		result = g.goType(m.Returns[0], typePrefix+"Result")
+	}
+
+	fmt.Fprintf(&g.out.decls, "// %s calls %s.", methodName, name)
+	if desc := firstLine(m.Description); desc != "" {
+		fmt.Fprintf(&g.out.decls, "\n//\n// %s", desc)
+	}
+	if m.Job {
+		fmt.Fprintf(&g.out.decls, "\n//\n// The method runs as a job, progress is reported to callback.")
+	}
+	g.out.decls.WriteString("\n")
+
+	signature := strings.Join(append([]string{"ctx context.Context"}, params...), ", ")
+	call := fmt.Sprintf("%q, []interface{}{%s}", name, strings.Join(args, ", "))
+	switch {
+	case m.Job:
+		fmt.Fprintf(&g.out.decls, "func (s *%s) %s(%s, callback truenas_api.JobCallback) (*truenas_api.Job, error) {\n", service, methodName, signature)
+		fmt.Fprintf(&g.out.decls, "\treturn s.client.CallJob(ctx, %s, callback)\n}\n\n", call)
+	case result == "":
+		fmt.Fprintf(&g.out.decls, "func (s *%s) %s(%s) error {\n", service, methodName, signature)
+		fmt.Fprintf(&g.out.decls, "\treturn s.client.CallResult(ctx, %s, nil)\n}\n\n", call)
+	default:
+		fmt.Fprintf(&g.out.decls, "func (s *%s) %s(%s) (%s, error) {\n", service, methodName, signature, result)
+		fmt.Fprintf(&g.out.decls, "\tvar result %s\n", result)
+		fmt.Fprintf(&g.out.decls, "\terr := s.client.CallResult(ctx, %s, &result)\n", call)
+		fmt.Fprintf(&g.out.decls, "\treturn result, err\n}\n\n")
+	}
+}
+
+// write formats the declarations of f and writes them to path.
+func (g *generator) write(path, pkg string, f *file, imports ...string) error {
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by truenas_gen from %s. DO NOT EDIT.\n\n", g.version)
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+	if len(imports) > 0 {
+		src.WriteString("import (\n")
+		for _, imp := range imports {
+			if imp == "" {
+				src.WriteString("\n") // Separates standard library and module imports
+				continue
+			}
+			fmt.Fprintf(&src, "\t%q\n", imp)
+		}
+		src.WriteString(")\n\n")
+	}
+	src.Write(f.decls.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format %s: %w", path, err)
+	}
+	return os.WriteFile(path, formatted, 0o644)
+}
+
+// loadSchema returns the output of core.get_methods, from a saved dump or the NAS.
+func loadSchema(schemaFile, serverURL string, verifySSL bool, user, pass, apiKey string, timeout time.Duration) (json.RawMessage, string, error) {
+	if schemaFile != "" {
+		data, err := os.ReadFile(schemaFile)
+		return data, "", err
+	}
+
+	client, err := truenas_api.NewClient(serverURL, verifySSL)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+	if apiKey != "" || (user != "" && pass != "") {
+		if err := client.Login(user, pass, apiKey); err != nil {
+			return nil, "", fmt.Errorf("login failed: %w", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var data json.RawMessage
+	if err := client.CallResult(ctx, "core.get_methods", nil, &data); err != nil {
+		return nil, "", err
+	}
+	version, err := truenas_api.NewSystem(client).Version(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, version, nil
+}
+
+func main() {
+	serverURL := flag.String("uri", "", "WebSocket server URI to read the schemas from (e.g., ws://localhost/api/current)")
+	verifySSL := flag.Bool("verifyssl", true, "Verify SSL certificates for wss:// connections")
+	user := flag.String("U", "", "Username for login")
+	pass := flag.String("P", "", "Password for login")
+	apiKey := flag.String("api-key", os.Getenv("TRUENAS_API_KEY"), "API key for login (defaults to $TRUENAS_API_KEY)")
+	timeout := flag.Int("timeout", 60, "Timeout in seconds for reading the schemas")
+	schemaFile := flag.String("schema", "", "Read the schemas from a saved core.get_methods result instead of --uri")
+	dump := flag.String("dump", "", "Also save the core.get_methods result to this file")
+	apiVersion := flag.String("api-version", "", "API version recorded in the generated code (default: system.version of --uri)")
+	outDir := flag.String("out", ".", "Directory to write the generated package to")
+	pkg := flag.String("package", "", "Name of the generated package (default: name of --out)")
+	namespaces := flag.String("namespaces", "", "Comma separated namespaces to generate, e.g. pool,pool.dataset (default all)")
+	flag.Parse()
+
+	if (*serverURL == "") == (*schemaFile == "") {
+		fmt.Println("Error: exactly one of --uri and --schema must be provided.")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	data, version, err := loadSchema(*schemaFile, *serverURL, *verifySSL, *user, *pass, *apiKey, time.Duration(*timeout)*time.Second)
+	if err != nil {
+		log.Fatalf("Failed to read the schemas: %v", err)
+	}
+	if *dump != "" {
+		if err := os.WriteFile(*dump, data, 0o644); err != nil {
+			log.Fatalf("Failed to save the schemas: %v", err)
+		}
+	}
+	if *apiVersion != "" {
+		version = *apiVersion
+	}
+	if version == "" {
+		log.Fatal("--api-version must be provided with --schema")
+	}
+
+	var methods map[string]truenas_api.MethodSchema
+	if err := json.Unmarshal(data, &methods); err != nil {
+		log.Fatalf("Failed to parse the schemas: %v", err)
+	}
+
+	wanted := make(map[string]bool)
+	for _, ns := range strings.Split(*namespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			wanted[ns] = true
+		}
+	}
+	byNamespace := make(map[string][]string)
+	for name := range methods {
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			continue
+		}
+		if ns := name[:i]; len(wanted) == 0 || wanted[ns] {
+			byNamespace[ns] = append(byNamespace[ns], name)
+		}
+	}
+	if len(byNamespace) == 0 {
+		log.Fatal("No methods to generate")
+	}
+	nsNames := make([]string, 0, len(byNamespace))
+	for ns := range byNamespace {
+		nsNames = append(nsNames, ns)
+	}
+	sort.Strings(nsNames)
+
+	if *pkg == "" {
+		abs, err := filepath.Abs(*outDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*pkg = filepath.Base(abs)
+	}
+	if !token.IsIdentifier(*pkg) {
+		log.Fatalf("Invalid package name %q, use --package", *pkg)
+	}
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		log.Fatal(err)
+	}
+
+	// Reserve the service names, so that schema types are numbered instead
+	g := &generator{version: version, types: make(map[string]string), pending: make(map[string]bool)}
+	for _, ns := range nsNames {
+		g.types[exportName(ns)] = ""
+		g.types["New"+exportName(ns)] = ""
+	}
+
+	for _, ns := range nsNames {
+		names := byNamespace[ns]
+		sort.Strings(names)
+		g.out = &file{}
+		g.namespace(ns, names, methods)
+		path := filepath.Join(*outDir, strings.ReplaceAll(ns, ".", "_")+".go")
+		if err := g.write(path, *pkg, g.out, "context", "", "truenas_api/truenas_api"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	g.out = &file{}
+	fmt.Fprintf(&g.out.decls, "// APIVersion is the TrueNAS version the bindings were generated from.\nconst APIVersion = %q\n", version)
+	if err := g.write(filepath.Join(*outDir, "api_version.go"), *pkg, g.out); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Generated %d namespaces for %s in %s", len(nsNames), version, *outDir)
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"log"
 	"os"
@@ -38,27 +38,17 @@ func main() {
 	}
 	log.Println("Login successful!")
 
-	// Example call to create a user
-	//       payload = {'name': API_KEY_NAME, 'allowlist': [{'resource': '*', 'method': '*'}]}
-	params2 := map[string]interface{}{
-		"resource": "*",
-		"method":   "*",
-	}
-
-	params := map[string]interface{}{
-		"name":      new_api_key_name,
-		"allowlist": []interface{}{params2},
-	}
-
-	// The params are wrapped in an array inside the Call function
-	res, err := client.Call("api_key.create", 10, []interface{}{params})
+	// Create an API key allowed to call every method
+	key, err := truenas_api.NewAPIKeys(client).Create(context.Background(), truenas_api.APIKeyCreateRequest{
+		Name:      new_api_key_name,
+		Username:  username,
+		Allowlist: []truenas_api.APIKeyAllowlistItem{{Method: "*", Resource: "*"}},
+	})
 	if err != nil {
 		log.Fatalf("failed to create api_key: %v", err)
 	}
-	//log.Printf("api_key created: %s", res)
-	var prettyJSON bytes.Buffer
-	json.Indent(&prettyJSON, res, "", "\t")
-	log.Printf("%s", prettyJSON.String())
+	prettyJSON, _ := json.MarshalIndent(key, "", "\t")
+	log.Printf("%s", prettyJSON)
 
 	// Graceful shutdown
 	client.Close()
@@ -1,43 +1,13 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"truenas_api/truenas_api"
 )
 
-// Define the structures to parse the JSON response
-type JSONRPCResponse struct {
-	JSONRPC string `json:"jsonrpc"`
-	Result  []App  `json:"result"`
-	ID      int    `json:"id"`
-}
-
-type App struct {
-	Name            string          `json:"name"`
-	ID              string          `json:"id"`
-	State           string          `json:"state"`
-	ActiveWorkloads ActiveWorkloads `json:"active_workloads"`
-	Metadata        Metadata        `json:"metadata"`
-}
-
-type ActiveWorkloads struct {
-	Containers       int               `json:"containers"`
-	ContainerDetails []ContainerDetail `json:"container_details"`
-}
-
-type ContainerDetail struct {
-	ServiceName string `json:"service_name"`
-	Image       string `json:"image"`
-	State       string `json:"state"`
-}
-
-type Metadata struct {
-	AppVersion string `json:"app_version"`
-}
-
 // example usage
 func main() {
 	if len(os.Args) < 2 {
@@ -69,23 +39,14 @@ func main() {
 
 	// client.Ping()
 
-	// Directly pass the list as a parameter to Call
-	params := []interface{}{}
-
-	response, err := client.Call("app.query", 10, []interface{}{params})
-	if err != nil {
-		log.Fatalf("failed to update apps: %v", err)
-	}
-
-	// Parse the JSON-RPC response
-	var rpcResponse JSONRPCResponse
-	err = json.Unmarshal(response, &rpcResponse)
+	// Query all apps through the typed Apps service
+	apps, err := truenas_api.NewApps(client).Query(context.Background(), nil, nil)
 	if err != nil {
-		log.Fatalf("failed to unmarshal response: %v", err)
+		log.Fatalf("failed to query apps: %v", err)
 	}
 
 	// Print the parsed data
-	for _, app := range rpcResponse.Result {
+	for _, app := range apps {
 		fmt.Printf("App Name: %s\n", app.Name)
 		fmt.Printf("App ID: %s\n", app.ID)
 		fmt.Printf("State: %s\n", app.State)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"truenas_api/truenas_api"
@@ -65,13 +66,10 @@ func main() {
 
 	loginClient(client)
 
-	// Subscribe to job updates
-	if err := client.SubscribeToJobs(); err != nil {
-		logFatalAndExit("Failed to subscribe to job updates: %v", err)
-	}
-
 	// Define the callback to handle job progress updates.
-	job, err := client.CallWithJob("app.upgrade", []interface{}{appName}, func(progress float64, state string, description string) {
+	// The Apps service subscribes to job updates on its own.
+	apps := truenas_api.NewApps(client)
+	job, err := apps.Upgrade(context.Background(), appName, nil, func(progress float64, state string, description string) {
 		log.Printf("Job Progress: %.2f%%, State: %s, Description: %s", progress, state, description)
 	})
 	if err != nil {
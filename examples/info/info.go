@@ -1,8 +1,7 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -41,15 +40,12 @@ func main() {
 	pres, perr := client.Ping()
 	fmt.Println(pres, perr)
 
-	// The params are wrapped in an array inside the Call function
-	res, err := client.Call("system.info", 10, []interface{}{})
+	info, err := truenas_api.NewSystem(client).Info(context.Background())
 	if err != nil {
-		log.Fatalf("failed to call system.info: %v", err)
+		log.Fatalf("failed to get system info: %v", err)
 	}
-
-	var prettyJSON bytes.Buffer
-	json.Indent(&prettyJSON, res, "", "\t")
-	log.Printf("Result: %s", prettyJSON.String())
+	log.Printf("%s running %s, up %s, load %.2f %.2f %.2f",
+		info.Hostname, info.Version, info.Uptime, info.Loadavg[0], info.Loadavg[1], info.Loadavg[2])
 
 	// Graceful shutdown
 	client.Close()
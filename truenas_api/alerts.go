@@ -0,0 +1,282 @@
+package truenas_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Alert levels reported in Alert.Level, from least to most severe.
+const (
+	AlertLevelInfo      = "INFO"
+	AlertLevelNotice    = "NOTICE"
+	AlertLevelWarning   = "WARNING"
+	AlertLevelError     = "ERROR"
+	AlertLevelCritical  = "CRITICAL"
+	AlertLevelAlert     = "ALERT"
+	AlertLevelEmergency = "EMERGENCY"
+)
+
+// Alert is an alert as returned by alert.list.
+type Alert struct {
+	UUID           string      `json:"uuid"`
+	Source         string      `json:"source"`
+	Klass          string      `json:"klass"` // Alert class, e.g. "ZpoolCapacityWarning"
+	Args           interface{} `json:"args"`
+	Node           string      `json:"node"`
+	Key            string      `json:"key"`
+	Datetime       *DateTime   `json:"datetime"`
+	LastOccurrence *DateTime   `json:"last_occurrence"`
+	Dismissed      bool        `json:"dismissed"`
+	Mail           interface{} `json:"mail"`
+	Text           string      `json:"text"` // Message template, see Formatted
+	ID             string      `json:"id"`
+	Level          string      `json:"level"` // One of the AlertLevel* constants
+	Formatted      string      `json:"formatted"`
+	OneShot        bool        `json:"one_shot"`
+}
+
+// Kinds of AlertEvent.
+const (
+	AlertAdded   = "added"
+	AlertChanged = "changed" // e.g. dismissed or restored
+	AlertCleared = "removed"
+)
+
+// AlertEvent is a change of the alert list delivered by Alerts.Watch.
+type AlertEvent struct {
+	Kind  string // AlertAdded, AlertChanged or AlertCleared
+	Alert Alert
+}
+
+// AlertCategory is a group of alert classes as returned by alert.list_categories.
+type AlertCategory struct {
+	ID      string            `json:"id"`
+	Title   string            `json:"title"`
+	Classes []AlertClassEntry `json:"classes"`
+}
+
+// AlertClassEntry describes an alert class.
+type AlertClassEntry struct {
+	ID               string `json:"id"`
+	Title            string `json:"title"`
+	Level            string `json:"level"`
+	ProactiveSupport bool   `json:"proactive_support"`
+}
+
+// AlertClassSetting overrides the level and notification policy of an alert class.
+type AlertClassSetting struct {
+	Level            string `json:"level,omitempty"`
+	Policy           string `json:"policy,omitempty"` // "IMMEDIATELY", "HOURLY", "DAILY" or "NEVER"
+	ProactiveSupport *bool  `json:"proactive_support,omitempty"`
+}
+
+// AlertService is an alert notification service as returned by alertservice.query.
+type AlertService struct {
+	ID         int                    `json:"id"`
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"` // e.g. "Mail", "Slack", "PagerDuty", "OpsGenie"
+	TypeTitle  string                 `json:"type__title"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Level      string                 `json:"level"` // Minimum level to notify about
+	Enabled    bool                   `json:"enabled"`
+}
+
+// AlertServiceRequest holds the writable fields of an alert service.
+type AlertServiceRequest struct {
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Level      string                 `json:"level"`
+	Enabled    bool                   `json:"enabled"`
+}
+
+// Alerts provides typed access to the alert.*, alertservice.* and alertclasses.* namespaces.
+type Alerts struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewAlerts creates a new Alerts service.
+func NewAlerts(client *Client) *Alerts {
+	return &Alerts{client: client}
+}
+
+// List returns the current alerts.
+func (a *Alerts) List(ctx context.Context) ([]Alert, error) {
+	var alerts []Alert
+	err := a.client.callResult(ctx, "alert.list", nil, &alerts)
+	return alerts, err
+}
+
+// Dismiss dismisses an alert by UUID.
+func (a *Alerts) Dismiss(ctx context.Context, uuid string) error {
+	return a.client.callResult(ctx, "alert.dismiss", []interface{}{uuid}, nil)
+}
+
+// Restore restores a dismissed alert by UUID.
+func (a *Alerts) Restore(ctx context.Context, uuid string) error {
+	return a.client.callResult(ctx, "alert.restore", []interface{}{uuid}, nil)
+}
+
+// Categories returns the alert classes grouped by category.
+func (a *Alerts) Categories(ctx context.Context) ([]AlertCategory, error) {
+	var categories []AlertCategory
+	err := a.client.callResult(ctx, "alert.list_categories", nil, &categories)
+	return categories, err
+}
+
+// Policies returns the available alert class notification policies.
+func (a *Alerts) Policies(ctx context.Context) ([]string, error) {
+	var policies []string
+	err := a.client.callResult(ctx, "alert.list_policies", nil, &policies)
+	return policies, err
+}
+
+// Watch delivers alert list changes until ctx is done or the connection closes,
+// at which point the channel is closed. Cleared alerts are reported with the
+// last known state of the alert.
+func (a *Alerts) Watch(ctx context.Context) (<-chan AlertEvent, error) {
+	subCtx, cancel := context.WithCancel(ctx)
+	events, err := a.client.Subscribe(subCtx, "alert.list")
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// Buffer events while the known alerts are listed, so a burst of events
+	// can't overflow the subscription in the meantime.
+	var buffered []Event
+	stop, drained := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(drained)
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				buffered = append(buffered, event)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	// Seed the known alerts, so that cleared alerts can be reported in full
+	current, err := a.List(ctx)
+	close(stop)
+	<-drained
+	if err != nil {
+		cancel() // Drop the subscription, nobody will drain it
+		return nil, err
+	}
+	known := make(map[string]Alert, len(current))
+	for _, alert := range current {
+		known[alert.UUID] = alert
+	}
+
+	alerts := make(chan AlertEvent)
+	go func() {
+		defer close(alerts)
+		defer cancel()
+		for _, event := range buffered {
+			a.deliver(ctx, event, known, alerts)
+		}
+		for event := range events {
+			a.deliver(ctx, event, known, alerts)
+		}
+	}()
+	return alerts, nil
+}
+
+// deliver applies an alert.list event to known and sends it on alerts.
+func (a *Alerts) deliver(ctx context.Context, event Event, known map[string]Alert, alerts chan<- AlertEvent) {
+	alert, ok := a.decodeEvent(event, known)
+	if !ok {
+		return // Ignore if the event can't be parsed
+	}
+	if event.Msg == AlertCleared {
+		delete(known, alert.UUID)
+	} else {
+		known[alert.UUID] = alert
+	}
+
+	select {
+	case alerts <- AlertEvent{Kind: event.Msg, Alert: alert}:
+	case <-ctx.Done():
+	}
+}
+
+// decodeEvent extracts the alert of an alert.list event, falling back to the
+// known alert for events that only carry the alert ID.
+func (a *Alerts) decodeEvent(event Event, known map[string]Alert) (Alert, bool) {
+	var alert Alert
+	if len(event.Fields) > 0 && string(event.Fields) != "null" {
+		if err := json.Unmarshal(event.Fields, &alert); err != nil {
+			return alert, false
+		}
+	}
+	if alert.UUID == "" && event.ID != nil {
+		alert.UUID = fmt.Sprint(event.ID)
+	}
+	if previous, exists := known[alert.UUID]; exists && alert.Klass == "" {
+		alert = previous
+	}
+	return alert, alert.UUID != ""
+}
+
+// QueryServices returns the alert services matching filters.
+func (a *Alerts) QueryServices(ctx context.Context, filters []Filter, opts *QueryOptions) ([]AlertService, error) {
+	var services []AlertService
+	err := a.client.callResult(ctx, "alertservice.query", queryParams(filters, opts), &services)
+	return services, err
+}
+
+// CreateService creates an alert service.
+func (a *Alerts) CreateService(ctx context.Context, req AlertServiceRequest) (*AlertService, error) {
+	var service AlertService
+	if err := a.client.callResult(ctx, "alertservice.create", []interface{}{req}, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// UpdateService replaces the writable fields of an alert service.
+func (a *Alerts) UpdateService(ctx context.Context, id int, req AlertServiceRequest) (*AlertService, error) {
+	var service AlertService
+	if err := a.client.callResult(ctx, "alertservice.update", []interface{}{id, req}, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+// DeleteService deletes an alert service.
+func (a *Alerts) DeleteService(ctx context.Context, id int) error {
+	return a.client.callResult(ctx, "alertservice.delete", []interface{}{id}, nil)
+}
+
+// TestService sends a test alert through the service described by req.
+func (a *Alerts) TestService(ctx context.Context, req AlertServiceRequest) (bool, error) {
+	var ok bool
+	err := a.client.callResult(ctx, "alertservice.test", []interface{}{req}, &ok)
+	return ok, err
+}
+
+// ClassSettings returns the per class overrides of alert levels and policies.
+func (a *Alerts) ClassSettings(ctx context.Context) (map[string]AlertClassSetting, error) {
+	var config struct {
+		Classes map[string]AlertClassSetting `json:"classes"`
+	}
+	err := a.client.callResult(ctx, "alertclasses.config", nil, &config)
+	return config.Classes, err
+}
+
+// UpdateClassSettings replaces the per class overrides of alert levels and policies.
+func (a *Alerts) UpdateClassSettings(ctx context.Context, classes map[string]AlertClassSetting) (map[string]AlertClassSetting, error) {
+	var config struct {
+		Classes map[string]AlertClassSetting `json:"classes"`
+	}
+	params := map[string]interface{}{"classes": classes}
+	err := a.client.callResult(ctx, "alertclasses.update", []interface{}{params}, &config)
+	return config.Classes, err
+}
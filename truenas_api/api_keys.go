@@ -0,0 +1,141 @@
+package truenas_api
+
+import (
+	"context"
+	"fmt"
+)
+
+// APIKey is an API key as returned by api_key.query.
+type APIKey struct {
+	ID             int                   `json:"id"`
+	Name           string                `json:"name"`
+	Username       string                `json:"username"`
+	UserIdentifier interface{}           `json:"user_identifier"` // UID of a local user, SID of a directory services user
+	Keyhash        string                `json:"keyhash"`
+	CreatedAt      *DateTime             `json:"created_at"`
+	ExpiresAt      *DateTime             `json:"expires_at"` // nil if the key never expires
+	Local          bool                  `json:"local"`
+	Revoked        bool                  `json:"revoked"`
+	RevokedReason  string                `json:"revoked_reason"`
+	Allowlist      []APIKeyAllowlistItem `json:"allowlist,omitempty"`
+	Key            string                `json:"key,omitempty"` // Only set by Create and Regenerate
+}
+
+// APIKeyAllowlistItem grants an API key access to a method on a resource.
+type APIKeyAllowlistItem struct {
+	Method   string `json:"method"`   // HTTP verb, "CALL", "SUBSCRIBE" or "*"
+	Resource string `json:"resource"` // Method or endpoint name, or "*"
+}
+
+// APIKeyCreateRequest holds the parameters of api_key.create.
+type APIKeyCreateRequest struct {
+	Name      string                `json:"name"`
+	Username  string                `json:"username,omitempty"`   // User the key authenticates as
+	ExpiresAt *DateTime             `json:"expires_at,omitempty"` // nil creates a key that never expires
+	Allowlist []APIKeyAllowlistItem `json:"allowlist,omitempty"`  // Only supported by releases that scope keys by allowlist
+}
+
+// APIKeyUpdateRequest holds the parameters of api_key.update. Nil fields are left unchanged.
+type APIKeyUpdateRequest struct {
+	Name      *string               `json:"name,omitempty"`
+	ExpiresAt *DateTime             `json:"expires_at,omitempty"`
+	Allowlist []APIKeyAllowlistItem `json:"allowlist,omitempty"`
+	Reset     bool                  `json:"reset,omitempty"` // Regenerate the key
+}
+
+// APIKeys provides typed access to the api_key.* namespace.
+type APIKeys struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewAPIKeys creates a new APIKeys service.
+func NewAPIKeys(client *Client) *APIKeys {
+	return &APIKeys{client: client}
+}
+
+// Query returns the API keys matching filters.
+func (k *APIKeys) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]APIKey, error) {
+	var keys []APIKey
+	err := k.client.callResult(ctx, "api_key.query", queryParams(filters, opts), &keys)
+	return keys, err
+}
+
+// Get returns a single API key by ID.
+func (k *APIKeys) Get(ctx context.Context, id int) (*APIKey, error) {
+	var key APIKey
+	if err := k.client.callResult(ctx, "api_key.get_instance", []interface{}{id}, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Create creates an API key. The returned APIKey.Key is the only chance to read the key.
+func (k *APIKeys) Create(ctx context.Context, req APIKeyCreateRequest) (*APIKey, error) {
+	var key APIKey
+	if err := k.client.callResult(ctx, "api_key.create", []interface{}{req}, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Update updates an API key.
+func (k *APIKeys) Update(ctx context.Context, id int, req APIKeyUpdateRequest) (*APIKey, error) {
+	var key APIKey
+	if err := k.client.callResult(ctx, "api_key.update", []interface{}{id, req}, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// Regenerate replaces the key of an API key, invalidating the previous one.
+func (k *APIKeys) Regenerate(ctx context.Context, id int) (*APIKey, error) {
+	return k.Update(ctx, id, APIKeyUpdateRequest{Reset: true})
+}
+
+// Delete deletes an API key.
+func (k *APIKeys) Delete(ctx context.Context, id int) error {
+	return k.client.callResult(ctx, "api_key.delete", []interface{}{id}, nil)
+}
+
+// Rotate replaces the API key oldID with a new key named name, carrying over its
+// user, expiry and allowlist. The new key is verified by logging in with it on a
+// second connection before the old key is deleted; if verification fails, the new
+// key is deleted and the old one is kept.
+func (k *APIKeys) Rotate(ctx context.Context, oldID int, name string) (*APIKey, error) {
+	old, err := k.Get(ctx, oldID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key %d: %w", oldID, err)
+	}
+
+	key, err := k.Create(ctx, APIKeyCreateRequest{
+		Name:      name,
+		Username:  old.Username,
+		ExpiresAt: old.ExpiresAt,
+		Allowlist: old.Allowlist,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	if err := k.verify(ctx, key.Key); err != nil {
+		if delErr := k.Delete(ctx, key.ID); delErr != nil {
+			return nil, fmt.Errorf("new API key failed verification: %w (and could not be deleted: %v)", err, delErr)
+		}
+		return nil, fmt.Errorf("new API key failed verification: %w", err)
+	}
+
+	if err := k.Delete(ctx, oldID); err != nil {
+		return key, fmt.Errorf("failed to delete old API key %d: %w", oldID, err)
+	}
+	return key, nil
+}
+
+// verify logs in with apiKey on a separate connection to the same server.
+func (k *APIKeys) verify(ctx context.Context, apiKey string) error {
+	client, err := newClient(ctx, k.client.URL(), k.client.verifySSL)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.login(ctx, "", "", apiKey)
+}
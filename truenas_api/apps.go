@@ -0,0 +1,324 @@
+package truenas_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// App states reported in App.State.
+const (
+	AppStateDeploying = "DEPLOYING"
+	AppStateRunning   = "RUNNING"
+	AppStateStopping  = "STOPPING"
+	AppStateStopped   = "STOPPED"
+	AppStateCrashed   = "CRASHED"
+)
+
+// App is a container application as returned by app.query.
+type App struct {
+	Name                  string                 `json:"name"`
+	ID                    string                 `json:"id"`
+	State                 string                 `json:"state"` // One of the AppState* constants
+	UpgradeAvailable      bool                   `json:"upgrade_available"`
+	LatestVersion         string                 `json:"latest_version"`
+	ImageUpdatesAvailable bool                   `json:"image_updates_available"`
+	CustomApp             bool                   `json:"custom_app"`
+	Migrated              bool                   `json:"migrated"`
+	HumanVersion          string                 `json:"human_version"`
+	Version               string                 `json:"version"`
+	Notes                 string                 `json:"notes"`
+	Portals               map[string]string      `json:"portals"`
+	ActiveWorkloads       AppActiveWorkloads     `json:"active_workloads"`
+	Metadata              AppMetadata            `json:"metadata"`
+	VersionDetails        map[string]interface{} `json:"version_details,omitempty"` // Only set with the "include_app_schema" extra option
+	Config                map[string]interface{} `json:"config,omitempty"`          // Only set with the "retrieve_config" extra option
+}
+
+// AppActiveWorkloads describes the running containers of an app.
+type AppActiveWorkloads struct {
+	Containers       int                      `json:"containers"`
+	UsedPorts        []AppPortConfig          `json:"used_ports"`
+	ContainerDetails []AppContainerDetail     `json:"container_details"`
+	Volumes          []AppVolumeMount         `json:"volumes"`
+	Images           []string                 `json:"images"`
+	Networks         []map[string]interface{} `json:"networks"`
+}
+
+// AppContainerDetail describes a single container of an app.
+type AppContainerDetail struct {
+	ID           string           `json:"id"`
+	ServiceName  string           `json:"service_name"`
+	Image        string           `json:"image"`
+	PortConfig   []AppPortConfig  `json:"port_config"`
+	State        string           `json:"state"` // "running", "starting", "exited", ...
+	VolumeMounts []AppVolumeMount `json:"volume_mounts"`
+}
+
+// AppPortConfig is a container port and the host ports it is published on.
+type AppPortConfig struct {
+	ContainerPort int           `json:"container_port"`
+	Protocol      string        `json:"protocol"`
+	HostPorts     []AppHostPort `json:"host_ports"`
+}
+
+// AppHostPort is a host address a container port is published on.
+type AppHostPort struct {
+	HostPort int    `json:"host_port"`
+	HostIP   string `json:"host_ip"`
+}
+
+// AppVolumeMount is a volume or bind mount of a container.
+type AppVolumeMount struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Mode        string `json:"mode"`
+	Type        string `json:"type"`
+}
+
+// AppMetadata is the catalog metadata of an app.
+type AppMetadata struct {
+	AppVersion     string            `json:"app_version"`
+	Capabilities   []AppCapability   `json:"capabilities"`
+	Categories     []string          `json:"categories"`
+	Description    string            `json:"description"`
+	Home           string            `json:"home"`
+	HostMounts     []AppHostMount    `json:"host_mounts"`
+	Icon           string            `json:"icon"`
+	Keywords       []string          `json:"keywords"`
+	LastUpdate     string            `json:"last_update"`
+	LibVersion     string            `json:"lib_version"`
+	LibVersionHash string            `json:"lib_version_hash"`
+	Maintainers    []AppMaintainer   `json:"maintainers"`
+	Name           string            `json:"name"`
+	RunAsContext   []AppRunAsContext `json:"run_as_context"`
+	Screenshots    []string          `json:"screenshots"`
+	Sources        []string          `json:"sources"`
+	Title          string            `json:"title"`
+	Train          string            `json:"train"`
+	Version        string            `json:"version"`
+}
+
+// AppCapability is a Linux capability required by an app.
+type AppCapability struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// AppHostMount is a host path mounted by an app.
+type AppHostMount struct {
+	HostPath    string `json:"hostPath"`
+	Description string `json:"description"`
+}
+
+// AppMaintainer is a maintainer of a catalog app.
+type AppMaintainer struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	URL   string `json:"url"`
+}
+
+// AppRunAsContext is a user/group an app runs as.
+type AppRunAsContext struct {
+	Description string `json:"description"`
+	UID         int    `json:"uid"`
+	GID         int    `json:"gid"`
+	UserName    string `json:"user_name"`
+	GroupName   string `json:"group_name"`
+}
+
+// AppCreateRequest holds the parameters of app.create.
+type AppCreateRequest struct {
+	AppName                   string                 `json:"app_name"`
+	CatalogApp                string                 `json:"catalog_app,omitempty"` // Catalog app to install, unless CustomApp is set
+	Train                     string                 `json:"train,omitempty"`       // Catalog train, defaults to "stable"
+	Version                   string                 `json:"version,omitempty"`     // Catalog app version, defaults to "latest"
+	Values                    map[string]interface{} `json:"values,omitempty"`
+	CustomApp                 bool                   `json:"custom_app,omitempty"`
+	CustomComposeConfig       map[string]interface{} `json:"custom_compose_config,omitempty"`
+	CustomComposeConfigString string                 `json:"custom_compose_config_string,omitempty"`
+}
+
+// AppUpgradeOptions holds the options of app.upgrade.
+type AppUpgradeOptions struct {
+	AppVersion        string                 `json:"app_version,omitempty"` // Defaults to "latest"
+	Values            map[string]interface{} `json:"values,omitempty"`
+	SnapshotHostpaths bool                   `json:"snapshot_hostpaths,omitempty"`
+}
+
+// AppDeleteOptions holds the options of app.delete.
+type AppDeleteOptions struct {
+	RemoveImages         bool `json:"remove_images"`
+	RemoveIXVolumes      bool `json:"remove_ix_volumes"`
+	ForceRemoveIXVolumes bool `json:"force_remove_ix_volumes"`
+	ForceRemoveCustomApp bool `json:"force_remove_custom_app"`
+}
+
+// AppUpgradeSummary is the result of app.upgrade_summary.
+type AppUpgradeSummary struct {
+	LatestVersion               string              `json:"latest_version"`
+	LatestHumanVersion          string              `json:"latest_human_version"`
+	UpgradeVersion              string              `json:"upgrade_version"`
+	UpgradeHumanVersion         string              `json:"upgrade_human_version"`
+	Changelog                   string              `json:"changelog"`
+	AvailableVersionsForUpgrade []AppVersionSummary `json:"available_versions_for_upgrade"`
+}
+
+// AppVersionSummary is a version an app can be upgraded to.
+type AppVersionSummary struct {
+	Version      string `json:"version"`
+	HumanVersion string `json:"human_version"`
+}
+
+// AppContainer identifies a container of an app, as returned by app.container_ids.
+type AppContainer struct {
+	ID          string `json:"id"`
+	ServiceName string `json:"service_name"`
+}
+
+// AppLogLine is a single line of container log output.
+type AppLogLine struct {
+	Timestamp string `json:"timestamp"`
+	Data      string `json:"data"`
+}
+
+// Apps provides typed access to the app.* namespace.
+type Apps struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewApps creates a new Apps service.
+func NewApps(client *Client) *Apps {
+	return &Apps{client: client}
+}
+
+// Query returns the apps matching filters.
+func (a *Apps) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]App, error) {
+	var apps []App
+	err := a.client.callResult(ctx, "app.query", queryParams(filters, opts), &apps)
+	return apps, err
+}
+
+// Get returns a single app by name.
+func (a *Apps) Get(ctx context.Context, name string) (*App, error) {
+	var app App
+	if err := a.client.callResult(ctx, "app.get_instance", []interface{}{name}, &app); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// AvailableUpgrades returns the apps that have a newer catalog version available.
+func (a *Apps) AvailableUpgrades(ctx context.Context) ([]App, error) {
+	return a.Query(ctx, []Filter{{"upgrade_available", "=", true}}, nil)
+}
+
+// Create installs an app from the catalog, or a custom app.
+func (a *Apps) Create(ctx context.Context, req AppCreateRequest, callback JobCallback) (*Job, error) {
+	return a.client.callJob(ctx, "app.create", []interface{}{req}, callback)
+}
+
+// Update changes the configuration values of an app.
+func (a *Apps) Update(ctx context.Context, name string, values map[string]interface{}, callback JobCallback) (*Job, error) {
+	params := map[string]interface{}{"values": values}
+	return a.client.callJob(ctx, "app.update", []interface{}{name, params}, callback)
+}
+
+// Upgrade upgrades an app. A nil opts upgrades to the latest version.
+func (a *Apps) Upgrade(ctx context.Context, name string, opts *AppUpgradeOptions, callback JobCallback) (*Job, error) {
+	if opts == nil {
+		opts = &AppUpgradeOptions{}
+	}
+	return a.client.callJob(ctx, "app.upgrade", []interface{}{name, opts}, callback)
+}
+
+// UpgradeSummary describes the upgrade of an app to version, or to the latest version if empty.
+func (a *Apps) UpgradeSummary(ctx context.Context, name, version string) (*AppUpgradeSummary, error) {
+	params := map[string]interface{}{}
+	if version != "" {
+		params["app_version"] = version
+	}
+	var summary AppUpgradeSummary
+	if err := a.client.callResult(ctx, "app.upgrade_summary", []interface{}{name, params}, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// RollbackVersions returns the versions an app can be rolled back to.
+func (a *Apps) RollbackVersions(ctx context.Context, name string) ([]string, error) {
+	var versions []string
+	err := a.client.callResult(ctx, "app.rollback_versions", []interface{}{name}, &versions)
+	return versions, err
+}
+
+// Rollback rolls an app back to version, optionally restoring the snapshot taken on upgrade.
+func (a *Apps) Rollback(ctx context.Context, name, version string, rollbackSnapshot bool, callback JobCallback) (*Job, error) {
+	params := map[string]interface{}{
+		"app_version":       version,
+		"rollback_snapshot": rollbackSnapshot,
+	}
+	return a.client.callJob(ctx, "app.rollback", []interface{}{name, params}, callback)
+}
+
+// Start starts an app.
+func (a *Apps) Start(ctx context.Context, name string, callback JobCallback) (*Job, error) {
+	return a.client.callJob(ctx, "app.start", []interface{}{name}, callback)
+}
+
+// Stop stops an app.
+func (a *Apps) Stop(ctx context.Context, name string, callback JobCallback) (*Job, error) {
+	return a.client.callJob(ctx, "app.stop", []interface{}{name}, callback)
+}
+
+// Redeploy recreates the containers of an app.
+func (a *Apps) Redeploy(ctx context.Context, name string, callback JobCallback) (*Job, error) {
+	return a.client.callJob(ctx, "app.redeploy", []interface{}{name}, callback)
+}
+
+// Delete removes an app.
+func (a *Apps) Delete(ctx context.Context, name string, opts AppDeleteOptions, callback JobCallback) (*Job, error) {
+	return a.client.callJob(ctx, "app.delete", []interface{}{name, opts}, callback)
+}
+
+// ContainerIDs returns the containers of an app, keyed by container ID.
+func (a *Apps) ContainerIDs(ctx context.Context, name string, aliveOnly bool) (map[string]AppContainer, error) {
+	var containers map[string]AppContainer
+	params := map[string]interface{}{"alive_only": aliveOnly}
+	err := a.client.callResult(ctx, "app.container_ids", []interface{}{name, params}, &containers)
+	return containers, err
+}
+
+// Logs follows the log of a container of an app, starting with the last tailLines
+// lines. The channel is closed when ctx is done or the connection closes.
+func (a *Apps) Logs(ctx context.Context, name, containerID string, tailLines int) (<-chan AppLogLine, error) {
+	args, err := json.Marshal(map[string]interface{}{
+		"app_name":     name,
+		"container_id": containerID,
+		"tail_lines":   tailLines,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode log arguments: %w", err)
+	}
+
+	events, err := a.client.Subscribe(ctx, "app.container_log_follow:"+string(args))
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make(chan AppLogLine)
+	go func() {
+		defer close(lines)
+		for event := range events {
+			var line AppLogLine
+			if err := json.Unmarshal(event.Fields, &line); err != nil {
+				continue // Ignore if the log line can't be parsed
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return lines, nil
+}
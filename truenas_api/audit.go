@@ -0,0 +1,136 @@
+package truenas_api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"time"
+)
+
+// Audited services.
+const (
+	AuditMiddleware = "MIDDLEWARE"
+	AuditSMB        = "SMB"
+	AuditSudo       = "SUDO"
+)
+
+// AuditEntry is an audit record as returned by audit.query.
+type AuditEntry struct {
+	AuditID          string          `json:"audit_id"`
+	MessageTimestamp int64           `json:"message_timestamp"` // Unix seconds
+	Timestamp        *DateTime       `json:"timestamp"`
+	Address          string          `json:"address"` // Client address
+	Username         string          `json:"username"`
+	Session          string          `json:"session"`
+	Service          string          `json:"service"` // One of the Audit* services
+	ServiceData      json.RawMessage `json:"service_data"`
+	Event            string          `json:"event"` // e.g. "AUTHENTICATION", "METHOD_CALL", "CREATE", "RENAME"
+	EventData        json.RawMessage `json:"event_data"`
+	Success          bool            `json:"success"`
+}
+
+// AuditQuery selects audit records. Zero fields don't restrict the result.
+type AuditQuery struct {
+	Services []string  // Audit* services, all if empty
+	Since    time.Time // Only records at or after Since
+	Until    time.Time // Only records before Until
+	Username string
+	Event    string
+	Success  *bool
+	Filters  []Filter // Additional filters, e.g. Filter{"address", "=", "10.0.0.5"}
+}
+
+// filters returns the query filters selecting the records.
+func (q AuditQuery) filters() []Filter {
+	filters := append([]Filter{}, q.Filters...)
+	if !q.Since.IsZero() {
+		filters = append(filters, Filter{"message_timestamp", ">=", q.Since.Unix()})
+	}
+	if !q.Until.IsZero() {
+		filters = append(filters, Filter{"message_timestamp", "<", q.Until.Unix()})
+	}
+	if q.Username != "" {
+		filters = append(filters, Filter{"username", "=", q.Username})
+	}
+	if q.Event != "" {
+		filters = append(filters, Filter{"event", "=", q.Event})
+	}
+	if q.Success != nil {
+		filters = append(filters, Filter{"success", "=", *q.Success})
+	}
+	return filters
+}
+
+// params returns the single dictionary parameter of audit.query and audit.export.
+func (q AuditQuery) params(opts *QueryOptions) map[string]interface{} {
+	params := map[string]interface{}{"query-filters": q.filters()}
+	if opts != nil {
+		params["query-options"] = opts
+	}
+	if len(q.Services) > 0 {
+		params["services"] = q.Services
+	}
+	return params
+}
+
+// Audit provides typed access to the audit.* namespace.
+type Audit struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewAudit creates a new Audit service.
+func NewAudit(client *Client) *Audit {
+	return &Audit{client: client}
+}
+
+// Query returns the audit records selected by q. Use opts to sort and limit,
+// e.g. OrderBy: []string{"-message_timestamp"}, Limit: 100.
+func (a *Audit) Query(ctx context.Context, q AuditQuery, opts *QueryOptions) ([]AuditEntry, error) {
+	if opts == nil {
+		opts = &QueryOptions{}
+	}
+	var entries []AuditEntry
+	err := a.client.callResult(ctx, "audit.query", []interface{}{q.params(opts)}, &entries)
+	return entries, err
+}
+
+// Iterate returns an iterator over the audit records selected by q, fetching
+// pageSize records at a time, oldest first unless opts sets an order.
+func (a *Audit) Iterate(ctx context.Context, q AuditQuery, opts *QueryOptions, pageSize int) *Iterator[AuditEntry] {
+	if opts == nil || len(opts.OrderBy) == 0 {
+		ordered := QueryOptions{}
+		if opts != nil {
+			ordered = *opts
+		}
+		ordered.OrderBy = []string{"message_timestamp"} // Stable paging
+		opts = &ordered
+	}
+	params := func(opts *QueryOptions) []interface{} {
+		return []interface{}{q.params(opts)}
+	}
+	return newIterator[AuditEntry](ctx, a.client, "audit.query", params, opts, pageSize)
+}
+
+// Export writes the audit records selected by q to w in format, one of "CSV",
+// "JSON" or "YAML". The report is generated on the NAS with audit.export and
+// downloaded via audit.download_report.
+func (a *Audit) Export(ctx context.Context, q AuditQuery, format string, w io.Writer) error {
+	params := q.params(nil)
+	params["export_format"] = format
+	job, err := a.client.callJob(ctx, "audit.export", []interface{}{params}, nil)
+	if err != nil {
+		return err
+	}
+	if err := job.Wait(ctx); err != nil {
+		return err
+	}
+	var reportPath string
+	if err := job.DecodeResult(&reportPath); err != nil {
+		return err
+	}
+
+	reportName := path.Base(reportPath) // The report is downloaded by its file name
+	args := []interface{}{map[string]interface{}{"report_name": reportName}}
+	return a.client.Download(ctx, "audit.download_report", args, reportName, w)
+}
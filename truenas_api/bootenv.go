@@ -0,0 +1,70 @@
+package truenas_api
+
+import (
+	"context"
+	"fmt"
+)
+
+// BootEnvironment is a boot environment as returned by bootenv.query.
+type BootEnvironment struct {
+	ID          string    `json:"id"`
+	Realname    string    `json:"realname"`
+	Name        string    `json:"name"`
+	Active      string    `json:"active"` // "N" if active now, "R" if active on reboot, "NR" if both
+	Activated   bool      `json:"activated"`
+	CanActivate bool      `json:"can_activate"`
+	Mountpoint  string    `json:"mountpoint"`
+	Space       string    `json:"space"`
+	Created     *DateTime `json:"created"`
+	Keep        bool      `json:"keep"` // Protected from automatic removal
+	Rawspace    int64     `json:"rawspace"`
+}
+
+// ActiveNow reports whether the system is running from this boot environment.
+func (b BootEnvironment) ActiveNow() bool {
+	return b.Active == "N" || b.Active == "NR"
+}
+
+// ActiveOnReboot reports whether the system boots this boot environment next.
+func (b BootEnvironment) ActiveOnReboot() bool {
+	return b.Active == "R" || b.Active == "NR"
+}
+
+// BootEnvironments provides typed access to the bootenv.* namespace.
+type BootEnvironments struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewBootEnvironments creates a new BootEnvironments service.
+func NewBootEnvironments(client *Client) *BootEnvironments {
+	return &BootEnvironments{client: client}
+}
+
+// Query returns the boot environments matching filters.
+func (b *BootEnvironments) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]BootEnvironment, error) {
+	var envs []BootEnvironment
+	err := b.client.callResult(ctx, "bootenv.query", queryParams(filters, opts), &envs)
+	return envs, err
+}
+
+// Activate makes the system boot the boot environment with id on the next reboot.
+func (b *BootEnvironments) Activate(ctx context.Context, id string) error {
+	var ok bool
+	if err := b.client.callResult(ctx, "bootenv.activate", []interface{}{id}, &ok); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("bootenv.activate: failed to activate %s", id)
+	}
+	return nil
+}
+
+// SetKeep sets whether the boot environment is protected from automatic removal.
+func (b *BootEnvironments) SetKeep(ctx context.Context, id string, keep bool) error {
+	return b.client.callResult(ctx, "bootenv.set_attribute", []interface{}{id, map[string]interface{}{"keep": keep}}, nil)
+}
+
+// Delete deletes a boot environment that is neither active now nor on reboot.
+func (b *BootEnvironments) Delete(ctx context.Context, id string, callback JobCallback) (*Job, error) {
+	return b.client.callJob(ctx, "bootenv.delete", []interface{}{id}, callback)
+}
@@ -0,0 +1,342 @@
+package truenas_api
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"time"
+)
+
+// Certificate create types accepted by Certificates.Create.
+const (
+	CertificateCreateImported = "CERTIFICATE_CREATE_IMPORTED"
+	CertificateCreateCSR      = "CERTIFICATE_CREATE_CSR"
+	CertificateCreateACME     = "CERTIFICATE_CREATE_ACME"
+	CertificateCreateInternal = "CERTIFICATE_CREATE_INTERNAL"
+)
+
+// CA create types accepted by CertificateAuthorities.Create.
+const (
+	CACreateImported     = "CA_CREATE_IMPORTED"
+	CACreateInternal     = "CA_CREATE_INTERNAL"
+	CACreateIntermediate = "CA_CREATE_INTERMEDIATE"
+)
+
+// Certificate is a certificate as returned by certificate.query. Certificate
+// authorities returned by certificateauthority.query share the same fields.
+type Certificate struct {
+	ID                 int                    `json:"id"`
+	Type               int                    `json:"type"`
+	Name               string                 `json:"name"`
+	Certificate        string                 `json:"certificate"` // PEM encoded certificate, empty for a CSR
+	PrivateKey         string                 `json:"privatekey"`
+	CSR                string                 `json:"CSR"`
+	ACMEURI            string                 `json:"acme_uri"`
+	RenewDays          int                    `json:"renew_days"`
+	CertType           string                 `json:"cert_type"` // "CERTIFICATE" or "CA"
+	CertTypeExisting   bool                   `json:"cert_type_existing"`
+	CertTypeCSR        bool                   `json:"cert_type_CSR"`
+	Issuer             interface{}            `json:"issuer"` // "external", "external - signature pending", or the signing CA
+	ChainList          []string               `json:"chain_list"`
+	KeyLength          int                    `json:"key_length"`
+	KeyType            string                 `json:"key_type"`
+	Country            string                 `json:"country"`
+	State              string                 `json:"state"`
+	City               string                 `json:"city"`
+	Organization       string                 `json:"organization"`
+	OrganizationalUnit string                 `json:"organizational_unit"`
+	Common             string                 `json:"common"`
+	SAN                []string               `json:"san"`
+	Email              string                 `json:"email"`
+	DN                 string                 `json:"DN"`
+	DigestAlgorithm    string                 `json:"digest_algorithm"`
+	Lifetime           int                    `json:"lifetime"` // Days
+	From               string                 `json:"from"`     // e.g. "Thu Mar 14 13:46:05 2024"
+	Until              string                 `json:"until"`
+	Serial             json.Number            `json:"serial"` // May exceed int64
+	Fingerprint        string                 `json:"fingerprint"`
+	Expired            bool                   `json:"expired"`
+	Parsed             bool                   `json:"parsed"`
+	Revoked            bool                   `json:"revoked"`
+	Extensions         map[string]interface{} `json:"extensions"`
+}
+
+// ExpiresAt returns the end of the validity period of the certificate, parsed
+// from the PEM certificate if possible and from Until otherwise.
+func (c Certificate) ExpiresAt() (time.Time, error) {
+	if block, _ := pem.Decode([]byte(c.Certificate)); block != nil {
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			return cert.NotAfter, nil
+		}
+	}
+	if c.Until == "" {
+		return time.Time{}, fmt.Errorf("certificate %s has no validity period", c.Name)
+	}
+	return time.Parse(time.ANSIC, c.Until)
+}
+
+// CertificateCreateRequest holds the fields of certificate.create. Which
+// fields are used depends on CreateType, see ImportPEM, CreateCSR and IssueACME.
+type CertificateCreateRequest struct {
+	CreateType         string         `json:"create_type"`
+	Name               string         `json:"name"`
+	Certificate        string         `json:"certificate,omitempty"`
+	PrivateKey         string         `json:"privatekey,omitempty"`
+	Passphrase         string         `json:"passphrase,omitempty"`
+	KeyType            string         `json:"key_type,omitempty"` // "RSA" or "EC"
+	KeyLength          int            `json:"key_length,omitempty"`
+	ECCurve            string         `json:"ec_curve,omitempty"`
+	DigestAlgorithm    string         `json:"digest_algorithm,omitempty"`
+	Lifetime           int            `json:"lifetime,omitempty"`
+	Country            string         `json:"country,omitempty"`
+	State              string         `json:"state,omitempty"`
+	City               string         `json:"city,omitempty"`
+	Organization       string         `json:"organization,omitempty"`
+	OrganizationalUnit string         `json:"organizational_unit,omitempty"`
+	Email              string         `json:"email,omitempty"`
+	Common             string         `json:"common,omitempty"`
+	SAN                []string       `json:"san,omitempty"`
+	SignedBy           int            `json:"signedby,omitempty"` // CA ID for internal certificates
+	CSRID              int            `json:"csr_id,omitempty"`   // CSR to issue an ACME certificate for
+	TOS                bool           `json:"tos,omitempty"`      // Accept the ACME terms of service
+	ACMEDirectoryURI   string         `json:"acme_directory_uri,omitempty"`
+	RenewDays          int            `json:"renew_days,omitempty"`
+	DNSMapping         map[string]int `json:"dns_mapping,omitempty"` // Domain to ACME DNS authenticator ID
+}
+
+// CertificateUpdateRequest holds the writable fields of an existing certificate.
+type CertificateUpdateRequest struct {
+	Name      string `json:"name"`
+	RenewDays int    `json:"renew_days,omitempty"` // Only applies to ACME certificates
+	Revoked   bool   `json:"revoked"`
+}
+
+// ACMEDNSAuthenticator is a DNS authenticator for ACME DNS-01 challenges.
+type ACMEDNSAuthenticator struct {
+	ID         int                    `json:"id"`
+	Name       string                 `json:"name"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// Certificates provides typed access to the certificate.* namespace.
+type Certificates struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewCertificates creates a new Certificates service.
+func NewCertificates(client *Client) *Certificates {
+	return &Certificates{client: client}
+}
+
+// Query returns the certificates and CSRs matching filters.
+func (c *Certificates) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]Certificate, error) {
+	var certs []Certificate
+	err := c.client.callResult(ctx, "certificate.query", queryParams(filters, opts), &certs)
+	return certs, err
+}
+
+// Get returns a certificate by ID.
+func (c *Certificates) Get(ctx context.Context, id int) (*Certificate, error) {
+	var cert Certificate
+	if err := c.client.callResult(ctx, "certificate.get_instance", []interface{}{id}, &cert); err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// Create creates a certificate or CSR. The job result is the new certificate.
+func (c *Certificates) Create(ctx context.Context, req CertificateCreateRequest, callback JobCallback) (*Job, error) {
+	return c.client.callJob(ctx, "certificate.create", []interface{}{req}, callback)
+}
+
+// ImportPEM imports a PEM encoded certificate, including its chain, and private key.
+func (c *Certificates) ImportPEM(ctx context.Context, name, certificate, privateKey string, callback JobCallback) (*Job, error) {
+	return c.Create(ctx, CertificateCreateRequest{
+		CreateType:  CertificateCreateImported,
+		Name:        name,
+		Certificate: certificate,
+		PrivateKey:  privateKey,
+	}, callback)
+}
+
+// CreateCSR creates a certificate signing request and its private key.
+// CreateType is set by CreateCSR.
+func (c *Certificates) CreateCSR(ctx context.Context, req CertificateCreateRequest, callback JobCallback) (*Job, error) {
+	req.CreateType = CertificateCreateCSR
+	if req.KeyType == "" {
+		req.KeyType, req.KeyLength = "RSA", 2048
+	}
+	if req.DigestAlgorithm == "" {
+		req.DigestAlgorithm = "SHA256"
+	}
+	return c.Create(ctx, req, callback)
+}
+
+// IssueACME issues a certificate for an existing CSR from an ACME directory,
+// e.g. "https://acme-v02.api.letsencrypt.org/directory", answering the DNS
+// challenges of each domain with the mapped authenticator. Accepting the
+// terms of service of the ACME server is implied.
+func (c *Certificates) IssueACME(ctx context.Context, name string, csrID int, directoryURI string, dnsMapping map[string]int, renewDays int, callback JobCallback) (*Job, error) {
+	return c.Create(ctx, CertificateCreateRequest{
+		CreateType:       CertificateCreateACME,
+		Name:             name,
+		CSRID:            csrID,
+		TOS:              true,
+		ACMEDirectoryURI: directoryURI,
+		DNSMapping:       dnsMapping,
+		RenewDays:        renewDays,
+	}, callback)
+}
+
+// Update replaces the writable fields of a certificate.
+func (c *Certificates) Update(ctx context.Context, id int, req CertificateUpdateRequest, callback JobCallback) (*Job, error) {
+	return c.client.callJob(ctx, "certificate.update", []interface{}{id, req}, callback)
+}
+
+// Delete deletes a certificate. With force set it is deleted even if in use.
+func (c *Certificates) Delete(ctx context.Context, id int, force bool, callback JobCallback) (*Job, error) {
+	return c.client.callJob(ctx, "certificate.delete", []interface{}{id, force}, callback)
+}
+
+// Expiring returns the certificates that expire within d, including those
+// already expired. CSRs and certificates that can't be parsed are skipped.
+func (c *Certificates) Expiring(ctx context.Context, d time.Duration) ([]Certificate, error) {
+	certs, err := c.Query(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return expiring(certs, d), nil
+}
+
+// expiring filters the certificates that expire within d.
+func expiring(certs []Certificate, d time.Duration) []Certificate {
+	limit := time.Now().Add(d)
+	var result []Certificate
+	for _, cert := range certs {
+		if cert.CertTypeCSR {
+			continue
+		}
+		if expiresAt, err := cert.ExpiresAt(); err == nil && expiresAt.Before(limit) {
+			result = append(result, cert)
+		}
+	}
+	return result
+}
+
+// ACMEDNSAuthenticators returns the configured ACME DNS authenticators.
+func (c *Certificates) ACMEDNSAuthenticators(ctx context.Context) ([]ACMEDNSAuthenticator, error) {
+	var authenticators []ACMEDNSAuthenticator
+	err := c.client.callResult(ctx, "acme.dns.authenticator.query", queryParams(nil, nil), &authenticators)
+	return authenticators, err
+}
+
+// SetUICertificate makes the web UI use the certificate with id. With
+// restartUI set the web UI is restarted to pick it up, which drops the
+// connections of web UI users.
+func (c *Certificates) SetUICertificate(ctx context.Context, id int, restartUI bool) error {
+	update := map[string]interface{}{"ui_certificate": id}
+	if err := c.client.callResult(ctx, "system.general.update", []interface{}{update}, nil); err != nil {
+		return err
+	}
+	if restartUI {
+		return c.client.callResult(ctx, "system.general.ui_restart", nil, nil)
+	}
+	return nil
+}
+
+// CACreateRequest holds the fields of certificateauthority.create. Which
+// fields are used depends on CreateType.
+type CACreateRequest struct {
+	CreateType         string   `json:"create_type"`
+	Name               string   `json:"name"`
+	Certificate        string   `json:"certificate,omitempty"`
+	PrivateKey         string   `json:"privatekey,omitempty"`
+	Passphrase         string   `json:"passphrase,omitempty"`
+	KeyType            string   `json:"key_type,omitempty"`
+	KeyLength          int      `json:"key_length,omitempty"`
+	ECCurve            string   `json:"ec_curve,omitempty"`
+	DigestAlgorithm    string   `json:"digest_algorithm,omitempty"`
+	Lifetime           int      `json:"lifetime,omitempty"`
+	Country            string   `json:"country,omitempty"`
+	State              string   `json:"state,omitempty"`
+	City               string   `json:"city,omitempty"`
+	Organization       string   `json:"organization,omitempty"`
+	OrganizationalUnit string   `json:"organizational_unit,omitempty"`
+	Email              string   `json:"email,omitempty"`
+	Common             string   `json:"common,omitempty"`
+	SAN                []string `json:"san,omitempty"`
+	SignedBy           int      `json:"signedby,omitempty"` // Parent CA ID for intermediate CAs
+}
+
+// CertificateAuthorities provides typed access to the certificateauthority.* namespace.
+type CertificateAuthorities struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewCertificateAuthorities creates a new CertificateAuthorities service.
+func NewCertificateAuthorities(client *Client) *CertificateAuthorities {
+	return &CertificateAuthorities{client: client}
+}
+
+// Query returns the certificate authorities matching filters.
+func (c *CertificateAuthorities) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]Certificate, error) {
+	var cas []Certificate
+	err := c.client.callResult(ctx, "certificateauthority.query", queryParams(filters, opts), &cas)
+	return cas, err
+}
+
+// Get returns a certificate authority by ID.
+func (c *CertificateAuthorities) Get(ctx context.Context, id int) (*Certificate, error) {
+	var ca Certificate
+	if err := c.client.callResult(ctx, "certificateauthority.get_instance", []interface{}{id}, &ca); err != nil {
+		return nil, err
+	}
+	return &ca, nil
+}
+
+// Create creates or imports a certificate authority.
+func (c *CertificateAuthorities) Create(ctx context.Context, req CACreateRequest) (*Certificate, error) {
+	var ca Certificate
+	if err := c.client.callResult(ctx, "certificateauthority.create", []interface{}{req}, &ca); err != nil {
+		return nil, err
+	}
+	return &ca, nil
+}
+
+// ImportPEM imports a PEM encoded CA certificate and, optionally, its private key.
+func (c *CertificateAuthorities) ImportPEM(ctx context.Context, name, certificate, privateKey string) (*Certificate, error) {
+	return c.Create(ctx, CACreateRequest{
+		CreateType:  CACreateImported,
+		Name:        name,
+		Certificate: certificate,
+		PrivateKey:  privateKey,
+	})
+}
+
+// SignCSR signs the CSR with csrID using the CA with caID and stores the
+// result as a new certificate called name.
+func (c *CertificateAuthorities) SignCSR(ctx context.Context, caID, csrID int, name string) (*Certificate, error) {
+	params := map[string]interface{}{"ca_id": caID, "csr_cert_id": csrID, "name": name}
+	var cert Certificate
+	if err := c.client.callResult(ctx, "certificateauthority.ca_sign_csr", []interface{}{params}, &cert); err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// Delete deletes a certificate authority.
+func (c *CertificateAuthorities) Delete(ctx context.Context, id int) error {
+	return c.client.callResult(ctx, "certificateauthority.delete", []interface{}{id}, nil)
+}
+
+// Expiring returns the certificate authorities that expire within d, including
+// those already expired. CAs that can't be parsed are skipped.
+func (c *CertificateAuthorities) Expiring(ctx context.Context, d time.Duration) ([]Certificate, error) {
+	cas, err := c.Query(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return expiring(cas, d), nil
+}
@@ -0,0 +1,423 @@
+package truenas_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// CloudCredentialAttributes are the provider specific attributes of a cloud credential.
+type CloudCredentialAttributes interface {
+	Provider() string // Provider name, e.g. "S3"
+}
+
+// S3Attributes are the attributes of an Amazon S3 or S3 compatible credential.
+type S3Attributes struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	Endpoint        string `json:"endpoint,omitempty"` // Empty for Amazon S3
+	Region          string `json:"region,omitempty"`
+	SkipRegion      bool   `json:"skip_region,omitempty"`
+	SignaturesV2    bool   `json:"signatures_v2,omitempty"`
+	MaxUploadParts  int    `json:"max_upload_parts,omitempty"`
+}
+
+// B2Attributes are the attributes of a Backblaze B2 credential.
+type B2Attributes struct {
+	Account string `json:"account"`
+	Key     string `json:"key"`
+}
+
+// AzureBlobAttributes are the attributes of a Microsoft Azure Blob Storage credential.
+type AzureBlobAttributes struct {
+	Account  string `json:"account"`
+	Key      string `json:"key"`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// GoogleCloudStorageAttributes are the attributes of a Google Cloud Storage credential.
+type GoogleCloudStorageAttributes struct {
+	ServiceAccountCredentials string `json:"service_account_credentials"` // JSON service account key
+}
+
+// DropboxAttributes are the attributes of a Dropbox credential.
+type DropboxAttributes struct {
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	Token        string `json:"token"`
+}
+
+// OneDriveAttributes are the attributes of a Microsoft OneDrive credential.
+type OneDriveAttributes struct {
+	ClientID     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	Token        string `json:"token"`
+	DriveType    string `json:"drive_type"` // "PERSONAL", "BUSINESS" or "DOCUMENT_LIBRARY"
+	DriveID      string `json:"drive_id"`
+}
+
+// FTPAttributes are the attributes of an FTP credential.
+type FTPAttributes struct {
+	Host string `json:"host"`
+	Port int    `json:"port,omitempty"`
+	User string `json:"user"`
+	Pass string `json:"pass,omitempty"`
+}
+
+// SFTPAttributes are the attributes of an SFTP credential.
+type SFTPAttributes struct {
+	Host       string `json:"host"`
+	Port       int    `json:"port,omitempty"`
+	User       string `json:"user"`
+	Pass       string `json:"pass,omitempty"`
+	PrivateKey *int   `json:"private_key,omitempty"` // Keychain SSH key pair ID
+}
+
+// WebDAVAttributes are the attributes of a WebDAV credential.
+type WebDAVAttributes struct {
+	URL    string `json:"url"`
+	Vendor string `json:"vendor"` // "NEXTCLOUD", "OWNCLOUD", "SHAREPOINT" or "OTHER"
+	User   string `json:"user"`
+	Pass   string `json:"pass"`
+}
+
+// StorjAttributes are the attributes of a Storj credential.
+type StorjAttributes struct {
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	Endpoint        string `json:"endpoint,omitempty"`
+}
+
+// GenericCloudAttributes are the attributes of a provider without a dedicated type.
+type GenericCloudAttributes struct {
+	ProviderName string
+	Values       map[string]interface{}
+}
+
+func (S3Attributes) Provider() string                 { return "S3" }
+func (B2Attributes) Provider() string                 { return "B2" }
+func (AzureBlobAttributes) Provider() string          { return "AZUREBLOB" }
+func (GoogleCloudStorageAttributes) Provider() string { return "GOOGLE_CLOUD_STORAGE" }
+func (DropboxAttributes) Provider() string            { return "DROPBOX" }
+func (OneDriveAttributes) Provider() string           { return "ONEDRIVE" }
+func (FTPAttributes) Provider() string                { return "FTP" }
+func (SFTPAttributes) Provider() string               { return "SFTP" }
+func (WebDAVAttributes) Provider() string             { return "WEBDAV" }
+func (StorjAttributes) Provider() string              { return "STORJ_IX" }
+func (a GenericCloudAttributes) Provider() string     { return a.ProviderName }
+
+// MarshalJSON encodes only the attribute values.
+func (a GenericCloudAttributes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Values)
+}
+
+// CloudCredential is a cloud credential as returned by cloudsync.credentials.query.
+type CloudCredential struct {
+	ID         int             `json:"id"`
+	Name       string          `json:"name"`
+	Provider   string          `json:"provider"`
+	Attributes json.RawMessage `json:"attributes"` // See DecodeAttributes
+}
+
+// DecodeAttributes decodes the attributes into the type matching the provider,
+// or *GenericCloudAttributes for providers without a dedicated type. Every type
+// is returned as a pointer.
+func (c CloudCredential) DecodeAttributes() (CloudCredentialAttributes, error) {
+	var attrs CloudCredentialAttributes
+	switch c.Provider {
+	case "S3":
+		attrs = &S3Attributes{}
+	case "B2":
+		attrs = &B2Attributes{}
+	case "AZUREBLOB":
+		attrs = &AzureBlobAttributes{}
+	case "GOOGLE_CLOUD_STORAGE":
+		attrs = &GoogleCloudStorageAttributes{}
+	case "DROPBOX":
+		attrs = &DropboxAttributes{}
+	case "ONEDRIVE":
+		attrs = &OneDriveAttributes{}
+	case "FTP":
+		attrs = &FTPAttributes{}
+	case "SFTP":
+		attrs = &SFTPAttributes{}
+	case "WEBDAV":
+		attrs = &WebDAVAttributes{}
+	case "STORJ_IX":
+		attrs = &StorjAttributes{}
+	default:
+		generic := GenericCloudAttributes{ProviderName: c.Provider}
+		if err := json.Unmarshal(c.Attributes, &generic.Values); err != nil {
+			return nil, fmt.Errorf("failed to parse %s attributes: %w", c.Provider, err)
+		}
+		return &generic, nil
+	}
+	if err := json.Unmarshal(c.Attributes, attrs); err != nil {
+		return nil, fmt.Errorf("failed to parse %s attributes: %w", c.Provider, err)
+	}
+	return attrs, nil
+}
+
+// CloudCredentialVerification is the result of cloudsync.credentials.verify.
+type CloudCredentialVerification struct {
+	Valid   bool   `json:"valid"`
+	Error   string `json:"error"`
+	Excerpt string `json:"excerpt"`
+}
+
+// credentialParams builds the create/update/verify parameters of a credential.
+func credentialParams(name string, attrs CloudCredentialAttributes) map[string]interface{} {
+	params := map[string]interface{}{
+		"provider":   attrs.Provider(),
+		"attributes": attrs,
+	}
+	if name != "" {
+		params["name"] = name
+	}
+	return params
+}
+
+// CloudCredentials provides typed access to the cloudsync.credentials.* namespace.
+type CloudCredentials struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewCloudCredentials creates a new CloudCredentials service.
+func NewCloudCredentials(client *Client) *CloudCredentials {
+	return &CloudCredentials{client: client}
+}
+
+// Query returns the cloud credentials matching filters.
+func (c *CloudCredentials) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]CloudCredential, error) {
+	var credentials []CloudCredential
+	err := c.client.callResult(ctx, "cloudsync.credentials.query", queryParams(filters, opts), &credentials)
+	return credentials, err
+}
+
+// Get returns a single cloud credential by ID.
+func (c *CloudCredentials) Get(ctx context.Context, id int) (*CloudCredential, error) {
+	var credential CloudCredential
+	if err := c.client.callResult(ctx, "cloudsync.credentials.get_instance", []interface{}{id}, &credential); err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// Create creates a cloud credential for the provider of attrs.
+func (c *CloudCredentials) Create(ctx context.Context, name string, attrs CloudCredentialAttributes) (*CloudCredential, error) {
+	var credential CloudCredential
+	if err := c.client.callResult(ctx, "cloudsync.credentials.create", []interface{}{credentialParams(name, attrs)}, &credential); err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// Update replaces the name and attributes of a cloud credential.
+func (c *CloudCredentials) Update(ctx context.Context, id int, name string, attrs CloudCredentialAttributes) (*CloudCredential, error) {
+	var credential CloudCredential
+	if err := c.client.callResult(ctx, "cloudsync.credentials.update", []interface{}{id, credentialParams(name, attrs)}, &credential); err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// Delete deletes a cloud credential.
+func (c *CloudCredentials) Delete(ctx context.Context, id int) error {
+	return c.client.callResult(ctx, "cloudsync.credentials.delete", []interface{}{id}, nil)
+}
+
+// Verify checks that attrs can be used to connect to the provider.
+func (c *CloudCredentials) Verify(ctx context.Context, attrs CloudCredentialAttributes) (*CloudCredentialVerification, error) {
+	var result CloudCredentialVerification
+	if err := c.client.callResult(ctx, "cloudsync.credentials.verify", []interface{}{credentialParams("", attrs)}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CloudSyncTask is a cloud sync task as returned by cloudsync.query.
+type CloudSyncTask struct {
+	ID                 int                    `json:"id"`
+	Description        string                 `json:"description"`
+	Path               string                 `json:"path"`
+	Credentials        CloudCredential        `json:"credentials"`
+	Attributes         map[string]interface{} `json:"attributes"` // Provider specific, e.g. "bucket" and "folder"
+	Schedule           Schedule               `json:"schedule"`
+	PreScript          string                 `json:"pre_script"`
+	PostScript         string                 `json:"post_script"`
+	Snapshot           bool                   `json:"snapshot"`
+	Include            []string               `json:"include"`
+	Exclude            []string               `json:"exclude"`
+	Args               string                 `json:"args"`
+	Enabled            bool                   `json:"enabled"`
+	Direction          string                 `json:"direction"`     // "PUSH" or "PULL"
+	TransferMode       string                 `json:"transfer_mode"` // "SYNC", "COPY" or "MOVE"
+	BWLimit            []CloudSyncBWLimit     `json:"bwlimit"`
+	Transfers          *int                   `json:"transfers"`
+	Encryption         bool                   `json:"encryption"`
+	FilenameEncryption bool                   `json:"filename_encryption"`
+	EncryptionPassword string                 `json:"encryption_password"`
+	EncryptionSalt     string                 `json:"encryption_salt"`
+	CreateEmptySrcDirs bool                   `json:"create_empty_src_dirs"`
+	FollowSymlinks     bool                   `json:"follow_symlinks"`
+	Job                map[string]interface{} `json:"job"` // Running or last job of the task, if any
+	Locked             bool                   `json:"locked"`
+}
+
+// CloudSyncBWLimit limits the bandwidth of a cloud sync task from a time of day on.
+type CloudSyncBWLimit struct {
+	Time      string `json:"time"`      // "HH:MM"
+	Bandwidth *int   `json:"bandwidth"` // Bytes per second, nil for unlimited
+}
+
+// CloudSyncTaskRequest holds the writable fields of a cloud sync task.
+type CloudSyncTaskRequest struct {
+	Description        string                 `json:"description"`
+	Path               string                 `json:"path"`
+	Credentials        int                    `json:"credentials"` // Cloud credential ID
+	Attributes         map[string]interface{} `json:"attributes"`
+	Schedule           Schedule               `json:"schedule"`
+	PreScript          string                 `json:"pre_script"`
+	PostScript         string                 `json:"post_script"`
+	Snapshot           bool                   `json:"snapshot"`
+	Include            []string               `json:"include"`
+	Exclude            []string               `json:"exclude"`
+	Args               string                 `json:"args"`
+	Enabled            bool                   `json:"enabled"`
+	Direction          string                 `json:"direction"`
+	TransferMode       string                 `json:"transfer_mode"`
+	BWLimit            []CloudSyncBWLimit     `json:"bwlimit"`
+	Transfers          *int                   `json:"transfers"`
+	Encryption         bool                   `json:"encryption"`
+	FilenameEncryption bool                   `json:"filename_encryption"`
+	EncryptionPassword string                 `json:"encryption_password"`
+	EncryptionSalt     string                 `json:"encryption_salt"`
+	CreateEmptySrcDirs bool                   `json:"create_empty_src_dirs"`
+	FollowSymlinks     bool                   `json:"follow_symlinks"`
+}
+
+// Request returns the writable fields of the task, to be modified and passed to Update.
+func (t CloudSyncTask) Request() CloudSyncTaskRequest {
+	return CloudSyncTaskRequest{
+		Description:        t.Description,
+		Path:               t.Path,
+		Credentials:        t.Credentials.ID,
+		Attributes:         t.Attributes,
+		Schedule:           t.Schedule,
+		PreScript:          t.PreScript,
+		PostScript:         t.PostScript,
+		Snapshot:           t.Snapshot,
+		Include:            t.Include,
+		Exclude:            t.Exclude,
+		Args:               t.Args,
+		Enabled:            t.Enabled,
+		Direction:          t.Direction,
+		TransferMode:       t.TransferMode,
+		BWLimit:            t.BWLimit,
+		Transfers:          t.Transfers,
+		Encryption:         t.Encryption,
+		FilenameEncryption: t.FilenameEncryption,
+		EncryptionPassword: t.EncryptionPassword,
+		EncryptionSalt:     t.EncryptionSalt,
+		CreateEmptySrcDirs: t.CreateEmptySrcDirs,
+		FollowSymlinks:     t.FollowSymlinks,
+	}
+}
+
+// CloudDirectoryEntry is an entry returned by cloudsync.list_directory.
+type CloudDirectoryEntry struct {
+	Path      string `json:"Path"`
+	Name      string `json:"Name"`
+	Size      int64  `json:"Size"`
+	MimeType  string `json:"MimeType"`
+	ModTime   string `json:"ModTime"`
+	IsDir     bool   `json:"IsDir"`
+	Decrypted bool   `json:"Decrypted"`
+}
+
+// CloudSync provides typed access to the cloudsync.* namespace.
+type CloudSync struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewCloudSync creates a new CloudSync service.
+func NewCloudSync(client *Client) *CloudSync {
+	return &CloudSync{client: client}
+}
+
+// Query returns the cloud sync tasks matching filters.
+func (c *CloudSync) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]CloudSyncTask, error) {
+	var tasks []CloudSyncTask
+	err := c.client.callResult(ctx, "cloudsync.query", queryParams(filters, opts), &tasks)
+	return tasks, err
+}
+
+// Get returns a single cloud sync task by ID.
+func (c *CloudSync) Get(ctx context.Context, id int) (*CloudSyncTask, error) {
+	var task CloudSyncTask
+	if err := c.client.callResult(ctx, "cloudsync.get_instance", []interface{}{id}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Create creates a cloud sync task.
+func (c *CloudSync) Create(ctx context.Context, req CloudSyncTaskRequest) (*CloudSyncTask, error) {
+	var task CloudSyncTask
+	if err := c.client.callResult(ctx, "cloudsync.create", []interface{}{req}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Update replaces the writable fields of a cloud sync task.
+func (c *CloudSync) Update(ctx context.Context, id int, req CloudSyncTaskRequest) (*CloudSyncTask, error) {
+	var task CloudSyncTask
+	if err := c.client.callResult(ctx, "cloudsync.update", []interface{}{id, req}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Delete deletes a cloud sync task.
+func (c *CloudSync) Delete(ctx context.Context, id int) error {
+	return c.client.callResult(ctx, "cloudsync.delete", []interface{}{id}, nil)
+}
+
+// Sync runs a cloud sync task now. With dryRun set, rclone only reports what it
+// would transfer. Transfer progress is reported to callback; the job's
+// ProgressCh only delivers updates to a receiver that is already waiting.
+func (c *CloudSync) Sync(ctx context.Context, id int, dryRun bool, callback JobCallback) (*Job, error) {
+	opts := map[string]interface{}{"dry_run": dryRun}
+	return c.client.callJob(ctx, "cloudsync.sync", []interface{}{id, opts}, callback)
+}
+
+// SyncOnetime runs a cloud sync described by req without saving it as a task.
+func (c *CloudSync) SyncOnetime(ctx context.Context, req CloudSyncTaskRequest, dryRun bool, callback JobCallback) (*Job, error) {
+	opts := map[string]interface{}{"dry_run": dryRun}
+	return c.client.callJob(ctx, "cloudsync.sync_onetime", []interface{}{req, opts}, callback)
+}
+
+// Abort aborts the running job of a cloud sync task.
+func (c *CloudSync) Abort(ctx context.Context, id int) error {
+	return c.client.callResult(ctx, "cloudsync.abort", []interface{}{id}, nil)
+}
+
+// ListDirectory lists a directory on the remote of credentialsID. attributes
+// selects the location, e.g. {"bucket": "backups", "folder": "/nas1"}.
+func (c *CloudSync) ListDirectory(ctx context.Context, credentialsID int, attributes map[string]interface{}) ([]CloudDirectoryEntry, error) {
+	params := map[string]interface{}{
+		"credentials": credentialsID,
+		"attributes":  attributes,
+	}
+	var entries []CloudDirectoryEntry
+	err := c.client.callResult(ctx, "cloudsync.list_directory", []interface{}{params}, &entries)
+	return entries, err
+}
+
+// ListBuckets returns the buckets available to a cloud credential.
+func (c *CloudSync) ListBuckets(ctx context.Context, credentialsID int) ([]CloudDirectoryEntry, error) {
+	var buckets []CloudDirectoryEntry
+	err := c.client.callResult(ctx, "cloudsync.list_buckets", []interface{}{credentialsID}, &buckets)
+	return buckets, err
+}
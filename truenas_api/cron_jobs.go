@@ -0,0 +1,125 @@
+package truenas_api
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// CronJob is a cron job as returned by cronjob.query.
+type CronJob struct {
+	ID          int      `json:"id"`
+	Enabled     bool     `json:"enabled"`
+	Stderr      bool     `json:"stderr"` // Hide standard error
+	Stdout      bool     `json:"stdout"` // Hide standard output
+	Schedule    Schedule `json:"schedule"`
+	Command     string   `json:"command"`
+	Description string   `json:"description"`
+	User        string   `json:"user"` // User the command runs as
+}
+
+// CronJobRequest holds the writable fields of a cron job. Empty schedule
+// fields are left to the server default.
+type CronJobRequest struct {
+	Enabled     bool     `json:"enabled"`
+	Stderr      bool     `json:"stderr"`
+	Stdout      bool     `json:"stdout"`
+	Schedule    Schedule `json:"schedule"` // Begin and End are not supported
+	Command     string   `json:"command"`
+	Description string   `json:"description"`
+	User        string   `json:"user"`
+}
+
+// MarshalJSON omits the empty schedule fields.
+func (r CronJobRequest) MarshalJSON() ([]byte, error) {
+	schedule := make(map[string]string)
+	for name, value := range map[string]string{
+		"minute": r.Schedule.Minute,
+		"hour":   r.Schedule.Hour,
+		"dom":    r.Schedule.Dom,
+		"month":  r.Schedule.Month,
+		"dow":    r.Schedule.Dow,
+	} {
+		if value != "" {
+			schedule[name] = value
+		}
+	}
+	type fields CronJobRequest // Without this method
+	return json.Marshal(struct {
+		fields
+		Schedule map[string]string `json:"schedule"`
+	}{fields(r), schedule})
+}
+
+// Request returns the writable fields of the cron job, to be modified and passed to Update.
+func (c CronJob) Request() CronJobRequest {
+	return CronJobRequest{
+		Enabled:     c.Enabled,
+		Stderr:      c.Stderr,
+		Stdout:      c.Stdout,
+		Schedule:    c.Schedule,
+		Command:     c.Command,
+		Description: c.Description,
+		User:        c.User,
+	}
+}
+
+// CronJobs provides typed access to the cronjob.* namespace.
+type CronJobs struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewCronJobs creates a new CronJobs service.
+func NewCronJobs(client *Client) *CronJobs {
+	return &CronJobs{client: client}
+}
+
+// Query returns the cron jobs matching filters.
+func (c *CronJobs) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]CronJob, error) {
+	var jobs []CronJob
+	err := c.client.callResult(ctx, "cronjob.query", queryParams(filters, opts), &jobs)
+	return jobs, err
+}
+
+// Get returns a single cron job by ID.
+func (c *CronJobs) Get(ctx context.Context, id int) (*CronJob, error) {
+	var job CronJob
+	if err := c.client.callResult(ctx, "cronjob.get_instance", []interface{}{id}, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Create creates a cron job. The schedule is validated before it is sent.
+func (c *CronJobs) Create(ctx context.Context, req CronJobRequest) (*CronJob, error) {
+	if err := req.Schedule.Validate(); err != nil {
+		return nil, err
+	}
+	var job CronJob
+	if err := c.client.callResult(ctx, "cronjob.create", []interface{}{req}, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Update replaces the writable fields of a cron job. The schedule is validated before it is sent.
+func (c *CronJobs) Update(ctx context.Context, id int, req CronJobRequest) (*CronJob, error) {
+	if err := req.Schedule.Validate(); err != nil {
+		return nil, err
+	}
+	var job CronJob
+	if err := c.client.callResult(ctx, "cronjob.update", []interface{}{id, req}, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Delete deletes a cron job.
+func (c *CronJobs) Delete(ctx context.Context, id int) error {
+	return c.client.callResult(ctx, "cronjob.delete", []interface{}{id}, nil)
+}
+
+// Run runs a cron job immediately. With skipDisabled set a disabled cron job
+// is not run. The job fails if the command exits with an error.
+func (c *CronJobs) Run(ctx context.Context, id int, skipDisabled bool, callback JobCallback) (*Job, error) {
+	return c.client.callJob(ctx, "cronjob.run", []interface{}{id, skipDisabled}, callback)
+}
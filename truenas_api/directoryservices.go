@@ -0,0 +1,342 @@
+package truenas_api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Directory service states reported by DirectoryServices.Status.
+const (
+	DirectoryServiceDisabled = "DISABLED"
+	DirectoryServiceHealthy  = "HEALTHY"
+	DirectoryServiceFaulted  = "FAULTED"
+	DirectoryServiceLeaving  = "LEAVING"
+	DirectoryServiceJoining  = "JOINING"
+)
+
+// DirectoryServicesStatus is the result of directoryservices.status.
+type DirectoryServicesStatus struct {
+	Type      *string `json:"type"`   // "ACTIVEDIRECTORY", "LDAP", "IPA", or nil if none is enabled
+	Status    *string `json:"status"` // One of the DirectoryService* states
+	StatusMsg *string `json:"status_msg"`
+}
+
+// Healthy reports whether the enabled directory service is healthy.
+func (s DirectoryServicesStatus) Healthy() bool {
+	return s.Status != nil && *s.Status == DirectoryServiceHealthy
+}
+
+// ADConfig is the Active Directory configuration (activedirectory.config).
+type ADConfig struct {
+	ID                  int      `json:"id"`
+	DomainName          string   `json:"domainname"`
+	BindName            string   `json:"bindname"`
+	VerboseLogging      bool     `json:"verbose_logging"`
+	AllowTrustedDomains bool     `json:"allow_trusted_doms"`
+	UseDefaultDomain    bool     `json:"use_default_domain"`
+	AllowDNSUpdates     bool     `json:"allow_dns_updates"`
+	DisableCache        bool     `json:"disable_freenas_cache"`
+	RestrictPAM         bool     `json:"restrict_pam"`
+	Site                *string  `json:"site"`
+	KerberosRealm       *int     `json:"kerberos_realm"`
+	KerberosPrincipal   string   `json:"kerberos_principal"`
+	Timeout             int      `json:"timeout"`
+	DNSTimeout          int      `json:"dns_timeout"`
+	NSSInfo             *string  `json:"nss_info"`
+	CreateComputer      string   `json:"createcomputer"` // OU for the computer account
+	NetBIOSName         string   `json:"netbiosname"`
+	NetBIOSAlias        []string `json:"netbiosalias"`
+	Enable              bool     `json:"enable"`
+}
+
+// ADRequest holds the writable fields of the Active Directory configuration.
+// BindPassword is only needed to join.
+type ADRequest struct {
+	DomainName          string   `json:"domainname"`
+	BindName            string   `json:"bindname,omitempty"`
+	BindPassword        string   `json:"bindpw,omitempty"`
+	VerboseLogging      bool     `json:"verbose_logging"`
+	AllowTrustedDomains bool     `json:"allow_trusted_doms"`
+	UseDefaultDomain    bool     `json:"use_default_domain"`
+	AllowDNSUpdates     bool     `json:"allow_dns_updates"`
+	DisableCache        bool     `json:"disable_freenas_cache"`
+	RestrictPAM         bool     `json:"restrict_pam"`
+	Site                *string  `json:"site"`
+	KerberosRealm       *int     `json:"kerberos_realm,omitempty"`
+	KerberosPrincipal   string   `json:"kerberos_principal,omitempty"`
+	Timeout             int      `json:"timeout,omitempty"`
+	DNSTimeout          int      `json:"dns_timeout,omitempty"`
+	NSSInfo             *string  `json:"nss_info,omitempty"`
+	CreateComputer      string   `json:"createcomputer,omitempty"`
+	NetBIOSName         string   `json:"netbiosname,omitempty"`
+	NetBIOSAlias        []string `json:"netbiosalias,omitempty"`
+	Enable              bool     `json:"enable"`
+}
+
+// Request returns the writable fields of the configuration, for use with DirectoryServices.UpdateAD.
+func (a ADConfig) Request() ADRequest {
+	return ADRequest{
+		DomainName:          a.DomainName,
+		BindName:            a.BindName,
+		VerboseLogging:      a.VerboseLogging,
+		AllowTrustedDomains: a.AllowTrustedDomains,
+		UseDefaultDomain:    a.UseDefaultDomain,
+		AllowDNSUpdates:     a.AllowDNSUpdates,
+		DisableCache:        a.DisableCache,
+		RestrictPAM:         a.RestrictPAM,
+		Site:                a.Site,
+		KerberosRealm:       a.KerberosRealm,
+		KerberosPrincipal:   a.KerberosPrincipal,
+		Timeout:             a.Timeout,
+		DNSTimeout:          a.DNSTimeout,
+		NSSInfo:             a.NSSInfo,
+		CreateComputer:      a.CreateComputer,
+		NetBIOSName:         a.NetBIOSName,
+		NetBIOSAlias:        a.NetBIOSAlias,
+		Enable:              a.Enable,
+	}
+}
+
+// LDAPConfig is the LDAP configuration (ldap.config).
+type LDAPConfig struct {
+	ID                   int      `json:"id"`
+	Hostname             []string `json:"hostname"`
+	BaseDN               string   `json:"basedn"`
+	BindDN               string   `json:"binddn"`
+	AnonBind             bool     `json:"anonbind"`
+	SSL                  string   `json:"ssl"` // "OFF", "ON" or "START_TLS"
+	Certificate          *int     `json:"certificate"`
+	ValidateCertificates bool     `json:"validate_certificates"`
+	DisableCache         bool     `json:"disable_freenas_cache"`
+	Timeout              int      `json:"timeout"`
+	DNSTimeout           int      `json:"dns_timeout"`
+	KerberosRealm        *int     `json:"kerberos_realm"`
+	KerberosPrincipal    string   `json:"kerberos_principal"`
+	AuxiliaryParameters  string   `json:"auxiliary_parameters"`
+	Schema               string   `json:"schema"` // "RFC2307" or "RFC2307BIS"
+	Enable               bool     `json:"enable"`
+}
+
+// LDAPRequest holds the writable fields of the LDAP configuration.
+type LDAPRequest struct {
+	Hostname             []string `json:"hostname"`
+	BaseDN               string   `json:"basedn"`
+	BindDN               string   `json:"binddn"`
+	BindPassword         string   `json:"bindpw,omitempty"`
+	AnonBind             bool     `json:"anonbind"`
+	SSL                  string   `json:"ssl,omitempty"`
+	Certificate          *int     `json:"certificate"`
+	ValidateCertificates bool     `json:"validate_certificates"`
+	DisableCache         bool     `json:"disable_freenas_cache"`
+	Timeout              int      `json:"timeout,omitempty"`
+	DNSTimeout           int      `json:"dns_timeout,omitempty"`
+	KerberosRealm        *int     `json:"kerberos_realm"`
+	KerberosPrincipal    string   `json:"kerberos_principal"`
+	AuxiliaryParameters  string   `json:"auxiliary_parameters"`
+	Schema               string   `json:"schema,omitempty"`
+	Enable               bool     `json:"enable"`
+}
+
+// Request returns the writable fields of the configuration, for use with DirectoryServices.UpdateLDAP.
+func (l LDAPConfig) Request() LDAPRequest {
+	return LDAPRequest{
+		Hostname:             l.Hostname,
+		BaseDN:               l.BaseDN,
+		BindDN:               l.BindDN,
+		AnonBind:             l.AnonBind,
+		SSL:                  l.SSL,
+		Certificate:          l.Certificate,
+		ValidateCertificates: l.ValidateCertificates,
+		DisableCache:         l.DisableCache,
+		Timeout:              l.Timeout,
+		DNSTimeout:           l.DNSTimeout,
+		KerberosRealm:        l.KerberosRealm,
+		KerberosPrincipal:    l.KerberosPrincipal,
+		AuxiliaryParameters:  l.AuxiliaryParameters,
+		Schema:               l.Schema,
+		Enable:               l.Enable,
+	}
+}
+
+// KerberosConfig is the global Kerberos configuration (kerberos.config).
+type KerberosConfig struct {
+	ID             int    `json:"id"`
+	AppDefaultsAux string `json:"appdefaults_aux"`
+	LibDefaultsAux string `json:"libdefaults_aux"`
+}
+
+// KerberosRealm is a Kerberos realm as returned by kerberos.realm.query.
+type KerberosRealm struct {
+	ID            int      `json:"id"`
+	Realm         string   `json:"realm"`
+	KDC           []string `json:"kdc"`
+	AdminServer   []string `json:"admin_server"`
+	KPasswdServer []string `json:"kpasswd_server"`
+}
+
+// KerberosRealmRequest holds the writable fields of a Kerberos realm.
+type KerberosRealmRequest struct {
+	Realm         string   `json:"realm"`
+	KDC           []string `json:"kdc"`
+	AdminServer   []string `json:"admin_server"`
+	KPasswdServer []string `json:"kpasswd_server"`
+}
+
+// KerberosKeytab is a Kerberos keytab as returned by kerberos.keytab.query.
+type KerberosKeytab struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// DirectoryServices provides typed access to the activedirectory.*, ldap.*,
+// kerberos.* and directoryservices.* namespaces.
+type DirectoryServices struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewDirectoryServices creates a new DirectoryServices service.
+func NewDirectoryServices(client *Client) *DirectoryServices {
+	return &DirectoryServices{client: client}
+}
+
+// Status returns the type and state of the enabled directory service.
+func (d *DirectoryServices) Status(ctx context.Context) (*DirectoryServicesStatus, error) {
+	var status DirectoryServicesStatus
+	if err := d.client.callResult(ctx, "directoryservices.status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// WaitHealthy polls the directory service status every interval until it is
+// HEALTHY or ctx is done. Use a context with a timeout, a join can take minutes.
+func (d *DirectoryServices) WaitHealthy(ctx context.Context, interval time.Duration) (*DirectoryServicesStatus, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		status, err := d.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if status.Healthy() {
+			return status, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			state, msg := "DISABLED", ""
+			if status.Status != nil {
+				state = *status.Status
+			}
+			if status.StatusMsg != nil {
+				msg = ": " + *status.StatusMsg
+			}
+			return status, fmt.Errorf("directory service is %s%s: %w", state, msg, ctx.Err())
+		}
+	}
+}
+
+// RefreshCache refreshes the cached users and groups of the directory service.
+func (d *DirectoryServices) RefreshCache(ctx context.Context, callback JobCallback) (*Job, error) {
+	return d.client.callJob(ctx, "directoryservices.cache_refresh", nil, callback)
+}
+
+// ADConfig returns the Active Directory configuration.
+func (d *DirectoryServices) ADConfig(ctx context.Context) (*ADConfig, error) {
+	var config ADConfig
+	if err := d.client.callResult(ctx, "activedirectory.config", nil, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UpdateAD replaces the Active Directory configuration.
+func (d *DirectoryServices) UpdateAD(ctx context.Context, req ADRequest, callback JobCallback) (*Job, error) {
+	return d.client.callJob(ctx, "activedirectory.update", []interface{}{req}, callback)
+}
+
+// JoinAD enables Active Directory and joins the domain with the credentials in
+// req. The job finishing doesn't mean the join is usable yet, see WaitHealthy.
+func (d *DirectoryServices) JoinAD(ctx context.Context, req ADRequest, callback JobCallback) (*Job, error) {
+	if req.BindName == "" || req.BindPassword == "" {
+		return nil, fmt.Errorf("joining %s requires a bind name and password", req.DomainName)
+	}
+	req.Enable = true
+	return d.UpdateAD(ctx, req, callback)
+}
+
+// LeaveAD leaves the Active Directory domain, deleting the computer account with the given credentials.
+func (d *DirectoryServices) LeaveAD(ctx context.Context, username, password string, callback JobCallback) (*Job, error) {
+	creds := map[string]interface{}{"username": username, "password": password}
+	return d.client.callJob(ctx, "activedirectory.leave", []interface{}{creds}, callback)
+}
+
+// ADDomainInfo returns information about the joined domain, e.g. its domain controllers.
+func (d *DirectoryServices) ADDomainInfo(ctx context.Context) (map[string]interface{}, error) {
+	var info map[string]interface{}
+	err := d.client.callResult(ctx, "activedirectory.domain_info", nil, &info)
+	return info, err
+}
+
+// LDAPConfig returns the LDAP configuration.
+func (d *DirectoryServices) LDAPConfig(ctx context.Context) (*LDAPConfig, error) {
+	var config LDAPConfig
+	if err := d.client.callResult(ctx, "ldap.config", nil, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UpdateLDAP replaces the LDAP configuration. Set Enable to bind to the LDAP
+// server; the job finishing doesn't mean the binding is usable yet, see WaitHealthy.
+func (d *DirectoryServices) UpdateLDAP(ctx context.Context, req LDAPRequest, callback JobCallback) (*Job, error) {
+	return d.client.callJob(ctx, "ldap.update", []interface{}{req}, callback)
+}
+
+// KerberosConfig returns the global Kerberos configuration.
+func (d *DirectoryServices) KerberosConfig(ctx context.Context) (*KerberosConfig, error) {
+	var config KerberosConfig
+	if err := d.client.callResult(ctx, "kerberos.config", nil, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UpdateKerberosConfig replaces the auxiliary parameters of the global Kerberos configuration.
+func (d *DirectoryServices) UpdateKerberosConfig(ctx context.Context, appDefaultsAux, libDefaultsAux string) (*KerberosConfig, error) {
+	update := map[string]interface{}{"appdefaults_aux": appDefaultsAux, "libdefaults_aux": libDefaultsAux}
+	var config KerberosConfig
+	if err := d.client.callResult(ctx, "kerberos.update", []interface{}{update}, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// QueryKerberosRealms returns the Kerberos realms matching filters.
+func (d *DirectoryServices) QueryKerberosRealms(ctx context.Context, filters []Filter, opts *QueryOptions) ([]KerberosRealm, error) {
+	var realms []KerberosRealm
+	err := d.client.callResult(ctx, "kerberos.realm.query", queryParams(filters, opts), &realms)
+	return realms, err
+}
+
+// CreateKerberosRealm creates a Kerberos realm.
+func (d *DirectoryServices) CreateKerberosRealm(ctx context.Context, req KerberosRealmRequest) (*KerberosRealm, error) {
+	var realm KerberosRealm
+	if err := d.client.callResult(ctx, "kerberos.realm.create", []interface{}{req}, &realm); err != nil {
+		return nil, err
+	}
+	return &realm, nil
+}
+
+// DeleteKerberosRealm deletes a Kerberos realm.
+func (d *DirectoryServices) DeleteKerberosRealm(ctx context.Context, id int) error {
+	return d.client.callResult(ctx, "kerberos.realm.delete", []interface{}{id}, nil)
+}
+
+// QueryKerberosKeytabs returns the Kerberos keytabs matching filters.
+func (d *DirectoryServices) QueryKerberosKeytabs(ctx context.Context, filters []Filter, opts *QueryOptions) ([]KerberosKeytab, error) {
+	var keytabs []KerberosKeytab
+	err := d.client.callResult(ctx, "kerberos.keytab.query", queryParams(filters, opts), &keytabs)
+	return keytabs, err
+}
@@ -0,0 +1,382 @@
+package truenas_api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Disk is a disk as returned by disk.query.
+type Disk struct {
+	Identifier    string  `json:"identifier"`
+	Name          string  `json:"name"` // Device name, e.g. "sda"
+	Subsystem     string  `json:"subsystem"`
+	Number        int     `json:"number"`
+	Serial        string  `json:"serial"`
+	LUNID         string  `json:"lunid"`
+	Size          int64   `json:"size"`
+	Description   string  `json:"description"`
+	TransferMode  string  `json:"transfermode"`
+	HDDStandby    string  `json:"hddstandby"`
+	AdvPowerMgmt  string  `json:"advpowermgmt"`
+	ToggleSMART   bool    `json:"togglesmart"`
+	SMARTOptions  string  `json:"smartoptions"`
+	Expiretime    *string `json:"expiretime"`
+	Critical      *int    `json:"critical"`      // Critical temperature threshold
+	Difference    *int    `json:"difference"`    // Temperature difference to report
+	Informational *int    `json:"informational"` // Informational temperature threshold
+	Model         string  `json:"model"`
+	RotationRate  *int    `json:"rotationrate"` // nil for SSDs
+	Type          string  `json:"type"`         // "HDD" or "SSD"
+	ZFSGUID       string  `json:"zfs_guid"`
+	Bus           string  `json:"bus"`
+	Devname       string  `json:"devname"`
+	Pool          string  `json:"pool"` // Only set with the "pools" extra option, see Disks.Query
+	SupportsSMART *bool   `json:"supports_smart"`
+}
+
+// DiskTemperatureAgg is the aggregated temperature of a disk over a number of days.
+type DiskTemperatureAgg struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+	Avg float64 `json:"avg"`
+}
+
+// Disk wipe modes accepted by Disks.Wipe.
+const (
+	DiskWipeQuick      = "QUICK"
+	DiskWipeFull       = "FULL"
+	DiskWipeFullRandom = "FULL_RANDOM"
+)
+
+// SMARTSchedule is the schedule of a SMART test. Unlike Schedule it has no
+// minute field, as SMART tests are scheduled on the hour.
+type SMARTSchedule struct {
+	Hour  string `json:"hour"`
+	Dom   string `json:"dom"` // Day of month
+	Month string `json:"month"`
+	Dow   string `json:"dow"` // Day of week
+}
+
+// SMARTTest is a scheduled SMART test as returned by smart.test.query.
+type SMARTTest struct {
+	ID       int           `json:"id"`
+	Schedule SMARTSchedule `json:"schedule"`
+	Desc     string        `json:"desc"`
+	AllDisks bool          `json:"all_disks"`
+	Disks    []string      `json:"disks"` // Disk identifiers, unless AllDisks is set
+	Type     string        `json:"type"`  // "LONG", "SHORT", "CONVEYANCE" or "OFFLINE"
+}
+
+// SMARTTestRequest holds the writable fields of a scheduled SMART test.
+type SMARTTestRequest struct {
+	Schedule SMARTSchedule `json:"schedule"`
+	Desc     string        `json:"desc"`
+	AllDisks bool          `json:"all_disks"`
+	Disks    []string      `json:"disks"`
+	Type     string        `json:"type"`
+}
+
+// SMARTManualTest is the outcome of starting a manual SMART test on one disk.
+type SMARTManualTest struct {
+	Disk               string    `json:"disk"`
+	Identifier         string    `json:"identifier"`
+	Error              string    `json:"error"` // Empty if the test started
+	ExpectedResultTime *DateTime `json:"expected_result_time"`
+	Job                *int64    `json:"job"`
+}
+
+// SMARTTestResults are the self-test results of a disk, as returned by smart.test.results.
+type SMARTTestResults struct {
+	Disk        string            `json:"disk"`
+	Tests       []SMARTTestResult `json:"tests"`
+	CurrentTest *SMARTCurrentTest `json:"current_test"` // nil if no test is running
+}
+
+// SMARTTestResult is a single entry of the SMART self-test log.
+type SMARTTestResult struct {
+	Num             int     `json:"num"`
+	Description     string  `json:"description"`
+	Status          string  `json:"status"` // "SUCCESS", "FAILED", "RUNNING", "ABORTED", ...
+	StatusVerbose   string  `json:"status_verbose"`
+	SegmentNumber   *int    `json:"segment_number"`
+	Remaining       float64 `json:"remaining"`
+	Lifetime        int     `json:"lifetime"` // Power on hours when the test ran
+	LBAOfFirstError *int64  `json:"lba_of_first_error"`
+}
+
+// SMARTCurrentTest is the progress of a running SMART test.
+type SMARTCurrentTest struct {
+	Progress int `json:"progress"`
+}
+
+// SMARTAttribute is a SMART attribute as returned by disk.smart_attributes.
+type SMARTAttribute struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Value      int    `json:"value"`
+	Worst      int    `json:"worst"`
+	Thresh     int    `json:"thresh"`
+	WhenFailed string `json:"when_failed"` // "-" or empty unless the attribute failed
+	Raw        struct {
+		Value  int64  `json:"value"`
+		String string `json:"string"`
+	} `json:"raw"`
+}
+
+// Failing reports whether the attribute is failing now or has failed before.
+func (a SMARTAttribute) Failing() bool {
+	if a.WhenFailed != "" && a.WhenFailed != "-" {
+		return true
+	}
+	return a.Thresh > 0 && a.Value <= a.Thresh
+}
+
+// DiskHealthReport lists the disk problems found by Disks.HealthReport.
+type DiskHealthReport struct {
+	FailingAttributes map[string][]SMARTAttribute  // Failing SMART attributes by disk name
+	FailedTests       map[string][]SMARTTestResult // Failed SMART self-tests by disk name
+	Missing           []PoolDeviceStatus           // Pool members that are faulted, unavailable, removed or offline
+	Unused            []Disk                       // Disks that are not part of any pool
+	Errors            map[string]error             // Disks that could not be checked
+}
+
+// Healthy reports whether the report found no problems and every disk could be checked.
+func (r *DiskHealthReport) Healthy() bool {
+	return len(r.FailingAttributes) == 0 && len(r.FailedTests) == 0 && len(r.Missing) == 0 && len(r.Errors) == 0
+}
+
+// PoolDeviceStatus is a leaf device of a pool and its status.
+type PoolDeviceStatus struct {
+	Pool   string `json:"pool"`
+	Name   string `json:"name"`
+	Disk   string `json:"disk"`   // Disk name, empty if the disk is gone
+	Status string `json:"status"` // e.g. "ONLINE", "FAULTED", "UNAVAIL", "REMOVED", or "AVAIL" for spares
+}
+
+// Missing reports whether the device is out of service. Spares that are
+// available are not.
+func (d PoolDeviceStatus) Missing() bool {
+	switch d.Status {
+	case "FAULTED", "UNAVAIL", "REMOVED", "OFFLINE":
+		return true
+	}
+	return false
+}
+
+// Disks provides typed access to the disk.* and smart.test.* namespaces.
+type Disks struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewDisks creates a new Disks service.
+func NewDisks(client *Client) *Disks {
+	return &Disks{client: client}
+}
+
+// Query returns the disks matching filters, including the pool each disk belongs to.
+func (d *Disks) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]Disk, error) {
+	// Set pools on a copy, leaving the caller's options untouched
+	var withPools QueryOptions
+	if opts != nil {
+		withPools = *opts
+	}
+	withPools.Extra = make(map[string]interface{}, len(withPools.Extra)+1)
+	if opts != nil {
+		for key, value := range opts.Extra {
+			withPools.Extra[key] = value
+		}
+	}
+	withPools.Extra["pools"] = true
+	var disks []Disk
+	err := d.client.callResult(ctx, "disk.query", queryParams(filters, &withPools), &disks)
+	return disks, err
+}
+
+// Unused returns the disks that are not part of any pool.
+func (d *Disks) Unused(ctx context.Context) ([]Disk, error) {
+	var disks []Disk
+	err := d.client.callResult(ctx, "disk.get_unused", []interface{}{false}, &disks)
+	return disks, err
+}
+
+// Temperatures returns the current temperature of the named disks, or of all
+// disks if names is empty. Disks without a temperature sensor map to nil.
+func (d *Disks) Temperatures(ctx context.Context, names ...string) (map[string]*float64, error) {
+	if names == nil {
+		names = []string{}
+	}
+	var temperatures map[string]*float64
+	err := d.client.callResult(ctx, "disk.temperatures", []interface{}{names}, &temperatures)
+	return temperatures, err
+}
+
+// TemperatureAgg returns the min, max and average temperature of the named disks over the last days.
+func (d *Disks) TemperatureAgg(ctx context.Context, days int, names ...string) (map[string]DiskTemperatureAgg, error) {
+	if names == nil {
+		names = []string{}
+	}
+	var temperatures map[string]DiskTemperatureAgg
+	err := d.client.callResult(ctx, "disk.temperature_agg", []interface{}{names, days}, &temperatures)
+	return temperatures, err
+}
+
+// Wipe wipes a disk by device name with one of the DiskWipe* modes.
+func (d *Disks) Wipe(ctx context.Context, name, mode string, callback JobCallback) (*Job, error) {
+	return d.client.callJob(ctx, "disk.wipe", []interface{}{name, mode}, callback)
+}
+
+// SMARTAttributes returns the SMART attributes of a disk by device name.
+func (d *Disks) SMARTAttributes(ctx context.Context, name string) ([]SMARTAttribute, error) {
+	var attributes []SMARTAttribute
+	err := d.client.callResult(ctx, "disk.smart_attributes", []interface{}{name}, &attributes)
+	return attributes, err
+}
+
+// QuerySMARTTests returns the scheduled SMART tests matching filters.
+func (d *Disks) QuerySMARTTests(ctx context.Context, filters []Filter, opts *QueryOptions) ([]SMARTTest, error) {
+	var tests []SMARTTest
+	err := d.client.callResult(ctx, "smart.test.query", queryParams(filters, opts), &tests)
+	return tests, err
+}
+
+// CreateSMARTTest schedules a SMART test.
+func (d *Disks) CreateSMARTTest(ctx context.Context, req SMARTTestRequest) (*SMARTTest, error) {
+	var test SMARTTest
+	if err := d.client.callResult(ctx, "smart.test.create", []interface{}{req}, &test); err != nil {
+		return nil, err
+	}
+	return &test, nil
+}
+
+// UpdateSMARTTest replaces the writable fields of a scheduled SMART test.
+func (d *Disks) UpdateSMARTTest(ctx context.Context, id int, req SMARTTestRequest) (*SMARTTest, error) {
+	var test SMARTTest
+	if err := d.client.callResult(ctx, "smart.test.update", []interface{}{id, req}, &test); err != nil {
+		return nil, err
+	}
+	return &test, nil
+}
+
+// DeleteSMARTTest deletes a scheduled SMART test.
+func (d *Disks) DeleteSMARTTest(ctx context.Context, id int) error {
+	return d.client.callResult(ctx, "smart.test.delete", []interface{}{id}, nil)
+}
+
+// RunSMARTTest starts a SMART test of testType on the disks with the given identifiers.
+func (d *Disks) RunSMARTTest(ctx context.Context, testType string, identifiers ...string) ([]SMARTManualTest, error) {
+	disks := make([]map[string]string, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		disks = append(disks, map[string]string{"identifier": identifier, "type": testType})
+	}
+	var results []SMARTManualTest
+	err := d.client.callResult(ctx, "smart.test.manual_test", []interface{}{disks}, &results)
+	return results, err
+}
+
+// SMARTTestResults returns the SMART self-test logs of the disks matching filters, e.g. Filter{"disk", "=", "sda"}.
+func (d *Disks) SMARTTestResults(ctx context.Context, filters []Filter) ([]SMARTTestResults, error) {
+	var results []SMARTTestResults
+	err := d.client.callResult(ctx, "smart.test.results", queryParams(filters, nil), &results)
+	return results, err
+}
+
+// PoolDevices returns the status of every leaf device of every pool.
+func (d *Disks) PoolDevices(ctx context.Context) ([]PoolDeviceStatus, error) {
+	var pools []struct {
+		Name     string                `json:"name"`
+		Topology map[string][]poolVdev `json:"topology"`
+	}
+	if err := d.client.callResult(ctx, "pool.query", queryParams(nil, nil), &pools); err != nil {
+		return nil, err
+	}
+
+	var devices []PoolDeviceStatus
+	for _, pool := range pools {
+		for _, vdevs := range pool.Topology {
+			for _, vdev := range vdevs {
+				devices = vdev.leaves(pool.Name, devices)
+			}
+		}
+	}
+	return devices, nil
+}
+
+// poolVdev is a node of a pool topology.
+type poolVdev struct {
+	Type     string     `json:"type"` // "DISK" for leaves, "MIRROR", "RAIDZ1", ... otherwise
+	Name     string     `json:"name"`
+	Disk     string     `json:"disk"`
+	Status   string     `json:"status"`
+	Children []poolVdev `json:"children"`
+}
+
+// leaves appends the leaf devices below v to devices.
+func (v poolVdev) leaves(pool string, devices []PoolDeviceStatus) []PoolDeviceStatus {
+	if len(v.Children) == 0 {
+		return append(devices, PoolDeviceStatus{Pool: pool, Name: v.Name, Disk: v.Disk, Status: v.Status})
+	}
+	for _, child := range v.Children {
+		devices = child.leaves(pool, devices)
+	}
+	return devices
+}
+
+// HealthReport checks all disks for failing SMART attributes and failed
+// self-tests, pools for members that are out of service, and lists the disks that
+// are not part of any pool. Disks whose SMART data can't be read are listed in
+// Errors rather than failing the whole report.
+func (d *Disks) HealthReport(ctx context.Context) (*DiskHealthReport, error) {
+	report := &DiskHealthReport{
+		FailingAttributes: map[string][]SMARTAttribute{},
+		FailedTests:       map[string][]SMARTTestResult{},
+		Errors:            map[string]error{},
+	}
+
+	disks, err := d.Query(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, disk := range disks {
+		if disk.SupportsSMART != nil && !*disk.SupportsSMART {
+			continue
+		}
+		attributes, err := d.SMARTAttributes(ctx, disk.Name)
+		if err != nil {
+			report.Errors[disk.Name] = err
+			continue
+		}
+		for _, attribute := range attributes {
+			if attribute.Failing() {
+				report.FailingAttributes[disk.Name] = append(report.FailingAttributes[disk.Name], attribute)
+			}
+		}
+	}
+
+	results, err := d.SMARTTestResults(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		for _, test := range result.Tests {
+			if test.Status == "FAILED" || strings.Contains(strings.ToLower(test.StatusVerbose), "fail") {
+				report.FailedTests[result.Disk] = append(report.FailedTests[result.Disk], test)
+			}
+		}
+	}
+
+	devices, err := d.PoolDevices(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pool members: %w", err)
+	}
+	for _, device := range devices {
+		if device.Missing() {
+			report.Missing = append(report.Missing, device)
+		}
+	}
+
+	if report.Unused, err = d.Unused(ctx); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,175 @@
+package truenas_api
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+// Download runs method via core.download and streams the file it produces
+// to w over HTTP, e.g. Download(ctx, "config.save", []interface{}{}, "config.db", f).
+// It returns once the file is transferred and the job finished.
+func (c *Client) Download(ctx context.Context, method string, args []interface{}, filename string, w io.Writer) error {
+	_, err := c.download(ctx, method, args, filename, w, nil)
+	return err
+}
+
+// download implements Download, reporting the progress of the job to callback
+// and returning the number of bytes written to w.
+func (c *Client) download(ctx context.Context, method string, args []interface{}, filename string, w io.Writer, callback JobCallback) (int64, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	if err := c.SubscribeToJobs(); err != nil {
+		return 0, err
+	}
+
+	var result []interface{} // [job ID, download URL]
+	if err := c.callResult(ctx, "core.download", []interface{}{method, args, filename}, &result); err != nil {
+		return 0, err
+	}
+	if len(result) != 2 {
+		return 0, fmt.Errorf("unexpected core.download result: %v", result)
+	}
+	jobID, ok1 := result[0].(float64)
+	path, ok2 := result[1].(string)
+	if !ok1 || !ok2 {
+		return 0, fmt.Errorf("unexpected core.download result: %v", result)
+	}
+	job := c.trackJob(ctx, int64(jobID), method, callback)
+
+	n, err := c.httpGet(ctx, path, w)
+	if err != nil {
+		return n, fmt.Errorf("failed to download %s: %w", filename, err)
+	}
+	return n, job.Wait(ctx)
+}
+
+// Upload runs method as a job with the contents of r as its file input, by
+// posting both to the /_upload endpoint, e.g. Upload(ctx, "config.upload", nil, "config.db", f, nil).
+// The request is authenticated with a short-lived token from auth.generate_token.
+func (c *Client) Upload(ctx context.Context, method string, args []interface{}, filename string, r io.Reader, callback JobCallback) (*Job, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	if err := c.SubscribeToJobs(); err != nil {
+		return nil, err
+	}
+	var token string
+	if err := c.callResult(ctx, "auth.generate_token", []interface{}{300}, &token); err != nil {
+		return nil, err
+	}
+	target, err := c.httpURL("/_upload")
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(map[string]interface{}{"method": method, "params": args})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode upload parameters: %w", err)
+	}
+
+	// Stream the multipart body instead of buffering the file
+	body, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		err := form.WriteField("data", string(data))
+		if err == nil {
+			var part io.Writer
+			if part, err = form.CreateFormFile("file", filename); err == nil {
+				_, err = io.Copy(part, r)
+			}
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, body)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Token "+token)
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s: %w", filename, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to upload %s: unexpected HTTP status %s", filename, resp.Status)
+	}
+
+	var result struct {
+		JobID int64 `json:"job_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse upload response: %w", err)
+	}
+	return c.trackJob(ctx, result.JobID, method, callback), nil
+}
+
+// httpGet fetches path from the HTTP server of the NAS and copies the response
+// body to w. It fails if the body is shorter or longer than the announced size.
+func (c *Client) httpGet(ctx context.Context, path string, w io.Writer) (int64, error) {
+	target, err := c.httpURL(path)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return n, err
+	}
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		return n, fmt.Errorf("received %d of %d bytes", n, resp.ContentLength)
+	}
+	return n, nil
+}
+
+// httpURL resolves path, e.g. "/_download/1?auth_token=...", against the
+// HTTP(S) address of the server the client is connected to.
+func (c *Client) httpURL(path string) (string, error) {
+	base, err := url.Parse(c.URL())
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	switch base.Scheme {
+	case "wss":
+		base.Scheme = "https"
+	case "ws":
+		base.Scheme = "http"
+	}
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %q: %w", path, err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// httpClient returns an HTTP client that verifies certificates like the WebSocket connection does.
+func (c *Client) httpClient() *http.Client {
+	if c.verifySSL {
+		return http.DefaultClient
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &http.Client{Transport: transport}
+}
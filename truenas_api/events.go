@@ -0,0 +1,129 @@
+package truenas_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Event is a collection_update notification delivered to an event subscriber.
+type Event struct {
+	Msg        string          `json:"msg"`        // Kind of update: "added", "changed" or "removed"
+	Collection string          `json:"collection"` // Name of the event source
+	ID         interface{}     `json:"id"`         // ID of the updated item, if any
+	Fields     json.RawMessage `json:"fields"`     // Fields of the updated item
+}
+
+// subscriptionBuffer is the number of events a subscriber may fall behind
+// before its subscription is ended.
+const subscriptionBuffer = 256
+
+// subscription is a single active core.subscribe registration.
+type subscription struct {
+	name     string        // Event source name passed to core.subscribe
+	events   chan Event    // Channel the events are delivered on
+	overflow chan struct{} // Closed when the subscriber fell behind
+	once     sync.Once     // Guards closing overflow
+	mu       sync.RWMutex  // Guards closing events against in-flight deliveries
+	closed   bool          // Indicates if events has been closed
+}
+
+// deliver queues an event for the subscriber unless the subscription has
+// ended. It never blocks: if the queue is full, the subscription is ended.
+func (s *subscription) deliver(event Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.events <- event:
+	default:
+		s.once.Do(func() { close(s.overflow) })
+	}
+}
+
+// end stops delivery and closes the event channel.
+func (s *subscription) end() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	close(s.events)
+}
+
+// Subscribe subscribes to an event source via core.subscribe and delivers its
+// events on the returned channel. The subscription ends, and the channel is
+// closed, when ctx is done, the connection closes, or the caller falls more
+// than subscriptionBuffer events behind; delivery never blocks the
+// connection's reader.
+func (c *Client) Subscribe(ctx context.Context, name string) (<-chan Event, error) {
+	sub := &subscription{
+		name:     name,
+		events:   make(chan Event, subscriptionBuffer),
+		overflow: make(chan struct{}),
+	}
+
+	// Register first, so events sent right after the subscription are kept
+	c.mu.Lock()
+	c.subs[sub] = true
+	closeChan := c.closeChan
+	c.mu.Unlock()
+
+	var subID string
+	if err := c.callResult(ctx, "core.subscribe", []interface{}{name}, &subID); err != nil {
+		c.mu.Lock()
+		delete(c.subs, sub)
+		c.mu.Unlock()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", name, err)
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.unsubscribe(subID)
+		case <-sub.overflow:
+			c.unsubscribe(subID)
+		case <-closeChan:
+		}
+
+		c.mu.Lock()
+		delete(c.subs, sub)
+		c.mu.Unlock()
+		sub.end()
+	}()
+
+	return sub.events, nil
+}
+
+// unsubscribe ends a subscription on the server. It is best effort, the
+// subscription is dropped locally either way.
+func (c *Client) unsubscribe(subID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c.callResult(ctx, "core.unsubscribe", []interface{}{subID}, nil)
+}
+
+// dispatchEvent delivers a collection_update message to all matching subscribers.
+func (c *Client) dispatchEvent(message []byte) {
+	var notification struct {
+		Params Event `json:"params"`
+	}
+	if err := json.Unmarshal(message, &notification); err != nil {
+		return // Ignore if the event can't be parsed
+	}
+
+	c.mu.Lock()
+	var targets []*subscription
+	for sub := range c.subs {
+		if sub.name == notification.Params.Collection {
+			targets = append(targets, sub)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, sub := range targets {
+		sub.deliver(notification.Params)
+	}
+}
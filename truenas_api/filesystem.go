@@ -0,0 +1,283 @@
+package truenas_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ACL types.
+const (
+	ACLTypeNFS4     = "NFS4"
+	ACLTypePOSIX1E  = "POSIX1E"
+	ACLTypeDisabled = "DISABLED"
+)
+
+// FileStat is the result of filesystem.stat.
+type FileStat struct {
+	Realpath       string   `json:"realpath"`
+	Type           string   `json:"type"` // "DIRECTORY", "FILE", "SYMLINK" or "OTHER"
+	Size           int64    `json:"size"`
+	AllocationSize int64    `json:"allocation_size"`
+	Mode           int      `json:"mode"`
+	MountID        int      `json:"mount_id"`
+	UID            int      `json:"uid"`
+	GID            int      `json:"gid"`
+	User           *string  `json:"user"`  // nil if the UID can't be resolved
+	Group          *string  `json:"group"` // nil if the GID can't be resolved
+	Atime          float64  `json:"atime"`
+	Mtime          float64  `json:"mtime"`
+	Ctime          float64  `json:"ctime"`
+	Btime          float64  `json:"btime"`
+	Dev            int64    `json:"dev"`
+	Inode          int64    `json:"inode"`
+	Nlink          int      `json:"nlink"`
+	ACL            bool     `json:"acl"` // Has a non-trivial ACL
+	IsMountpoint   bool     `json:"is_mountpoint"`
+	IsCtldir       bool     `json:"is_ctldir"`
+	Attributes     []string `json:"attributes"`
+}
+
+// DirEntry is an entry returned by filesystem.listdir.
+type DirEntry struct {
+	Name           string   `json:"name"`
+	Path           string   `json:"path"`
+	Realpath       string   `json:"realpath"`
+	Type           string   `json:"type"` // "DIRECTORY", "FILE", "SYMLINK" or "OTHER"
+	Size           int64    `json:"size"`
+	AllocationSize int64    `json:"allocation_size"`
+	Mode           int      `json:"mode"`
+	MountID        int      `json:"mount_id"`
+	ACL            bool     `json:"acl"`
+	UID            int      `json:"uid"`
+	GID            int      `json:"gid"`
+	IsMountpoint   bool     `json:"is_mountpoint"`
+	IsCtldir       bool     `json:"is_ctldir"`
+	Attributes     []string `json:"attributes"`
+	Xattrs         []string `json:"xattrs"`
+}
+
+// NFS4ACE is an entry of an NFSv4 ACL.
+type NFS4ACE struct {
+	Tag   string    `json:"tag"` // "owner@", "group@", "everyone@", "USER" or "GROUP"
+	ID    *int      `json:"id"`  // UID or GID for USER and GROUP entries
+	Who   string    `json:"who,omitempty"`
+	Type  string    `json:"type"` // "ALLOW" or "DENY"
+	Perms NFS4Perms `json:"perms"`
+	Flags NFS4Flags `json:"flags"`
+}
+
+// NFS4Perms are the permissions of an NFSv4 ACL entry, either Basic
+// ("FULL_CONTROL", "MODIFY", "READ", "TRAVERSE") or the advanced flags.
+type NFS4Perms struct {
+	Basic           string `json:"BASIC,omitempty"`
+	ReadData        bool   `json:"READ_DATA,omitempty"`
+	WriteData       bool   `json:"WRITE_DATA,omitempty"`
+	AppendData      bool   `json:"APPEND_DATA,omitempty"`
+	ReadNamedAttrs  bool   `json:"READ_NAMED_ATTRS,omitempty"`
+	WriteNamedAttrs bool   `json:"WRITE_NAMED_ATTRS,omitempty"`
+	Execute         bool   `json:"EXECUTE,omitempty"`
+	DeleteChild     bool   `json:"DELETE_CHILD,omitempty"`
+	ReadAttributes  bool   `json:"READ_ATTRIBUTES,omitempty"`
+	WriteAttributes bool   `json:"WRITE_ATTRIBUTES,omitempty"`
+	Delete          bool   `json:"DELETE,omitempty"`
+	ReadACL         bool   `json:"READ_ACL,omitempty"`
+	WriteACL        bool   `json:"WRITE_ACL,omitempty"`
+	WriteOwner      bool   `json:"WRITE_OWNER,omitempty"`
+	Synchronize     bool   `json:"SYNCHRONIZE,omitempty"`
+}
+
+// NFS4Flags are the inheritance flags of an NFSv4 ACL entry, either Basic
+// ("INHERIT" or "NOINHERIT") or the advanced flags.
+type NFS4Flags struct {
+	Basic              string `json:"BASIC,omitempty"`
+	FileInherit        bool   `json:"FILE_INHERIT,omitempty"`
+	DirectoryInherit   bool   `json:"DIRECTORY_INHERIT,omitempty"`
+	NoPropagateInherit bool   `json:"NO_PROPAGATE_INHERIT,omitempty"`
+	InheritOnly        bool   `json:"INHERIT_ONLY,omitempty"`
+	Inherited          bool   `json:"INHERITED,omitempty"`
+}
+
+// NFS4ACLFlags are the flags of an NFSv4 ACL as a whole.
+type NFS4ACLFlags struct {
+	Autoinherit bool `json:"autoinherit"`
+	Protected   bool `json:"protected"`
+	Defaulted   bool `json:"defaulted"`
+}
+
+// POSIXACE is an entry of a POSIX.1e ACL.
+type POSIXACE struct {
+	Tag     string     `json:"tag"` // "USER_OBJ", "GROUP_OBJ", "USER", "GROUP", "OTHER" or "MASK"
+	ID      int        `json:"id"`  // UID or GID for USER and GROUP entries, -1 otherwise
+	Who     string     `json:"who,omitempty"`
+	Perms   POSIXPerms `json:"perms"`
+	Default bool       `json:"default"` // Part of the default ACL of a directory
+}
+
+// POSIXPerms are the permissions of a POSIX.1e ACL entry.
+type POSIXPerms struct {
+	Read    bool `json:"READ"`
+	Write   bool `json:"WRITE"`
+	Execute bool `json:"EXECUTE"`
+}
+
+// FileACL is the result of filesystem.getacl. Depending on ACLType either
+// NFS4 or POSIX holds the entries.
+type FileACL struct {
+	Path      string        `json:"path"`
+	ACLType   string        `json:"acltype"` // One of the ACLType* types
+	Trivial   bool          `json:"trivial"` // The ACL is equivalent to the file mode
+	UID       int           `json:"uid"`
+	GID       int           `json:"gid"`
+	User      *string       `json:"user"`
+	Group     *string       `json:"group"`
+	NFS4Flags *NFS4ACLFlags `json:"flags,omitempty"` // NFSv4 only
+	NFS4      []NFS4ACE     `json:"-"`
+	POSIX     []POSIXACE    `json:"-"`
+}
+
+// UnmarshalJSON decodes the acl field into NFS4 or POSIX according to acltype.
+func (a *FileACL) UnmarshalJSON(data []byte) error {
+	type plain FileACL
+	var raw struct {
+		plain
+		ACL json.RawMessage `json:"acl"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*a = FileACL(raw.plain)
+	if len(raw.ACL) == 0 || string(raw.ACL) == "null" {
+		return nil
+	}
+	switch a.ACLType {
+	case ACLTypeNFS4:
+		return json.Unmarshal(raw.ACL, &a.NFS4)
+	case ACLTypePOSIX1E:
+		return json.Unmarshal(raw.ACL, &a.POSIX)
+	}
+	return nil
+}
+
+// SetACLOptions are the options of Filesystem.SetACL.
+type SetACLOptions struct {
+	StripACL             bool `json:"stripacl"`               // Remove the ACL, leaving only the mode
+	Recursive            bool `json:"recursive"`              // Apply to all files and directories below the path
+	Traverse             bool `json:"traverse"`               // Cross into child datasets when recursive
+	Canonicalize         bool `json:"canonicalize"`           // Sort NFSv4 entries in canonical order
+	ValidateEffectiveACL bool `json:"validate_effective_acl"` // Check that USER and GROUP entries can access the path
+}
+
+// PermOptions are the options of Filesystem.SetPerm and Filesystem.Chown.
+type PermOptions struct {
+	StripACL  bool `json:"stripacl,omitempty"` // SetPerm only
+	Recursive bool `json:"recursive"`
+	Traverse  bool `json:"traverse"`
+}
+
+// Filesystem provides typed access to the filesystem.* namespace.
+type Filesystem struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewFilesystem creates a new Filesystem service.
+func NewFilesystem(client *Client) *Filesystem {
+	return &Filesystem{client: client}
+}
+
+// Stat returns information about a path.
+func (f *Filesystem) Stat(ctx context.Context, path string) (*FileStat, error) {
+	var stat FileStat
+	if err := f.client.callResult(ctx, "filesystem.stat", []interface{}{path}, &stat); err != nil {
+		return nil, err
+	}
+	return &stat, nil
+}
+
+// ListDir returns an iterator over the entries of a directory matching
+// filters, e.g. Filter{"type", "=", "DIRECTORY"}, fetching 100 entries at a time.
+func (f *Filesystem) ListDir(ctx context.Context, path string, filters []Filter, opts *QueryOptions) *Iterator[DirEntry] {
+	params := func(opts *QueryOptions) []interface{} {
+		return append([]interface{}{path}, queryParams(filters, opts)...)
+	}
+	return newIterator[DirEntry](ctx, f.client, "filesystem.listdir", params, opts, 100)
+}
+
+// GetACL returns the ACL of a path. With simplified set, NFSv4 permissions
+// and flags are reported as basic values where possible.
+func (f *Filesystem) GetACL(ctx context.Context, path string, simplified bool) (*FileACL, error) {
+	var acl FileACL
+	if err := f.client.callResult(ctx, "filesystem.getacl", []interface{}{path, simplified, true}, &acl); err != nil {
+		return nil, err
+	}
+	return &acl, nil
+}
+
+// SetNFS4ACL replaces the NFSv4 ACL of a path. A nil uid or gid leaves the owner unchanged.
+func (f *Filesystem) SetNFS4ACL(ctx context.Context, path string, acl []NFS4ACE, flags *NFS4ACLFlags, uid, gid *int, opts SetACLOptions, callback JobCallback) (*Job, error) {
+	req := map[string]interface{}{"path": path, "dacl": acl, "acltype": ACLTypeNFS4, "options": opts}
+	if flags != nil {
+		req["nfs41_flags"] = flags
+	}
+	return f.setACL(ctx, req, uid, gid, callback)
+}
+
+// SetPOSIXACL replaces the POSIX.1e ACL of a path. A nil uid or gid leaves the owner unchanged.
+func (f *Filesystem) SetPOSIXACL(ctx context.Context, path string, acl []POSIXACE, uid, gid *int, opts SetACLOptions, callback JobCallback) (*Job, error) {
+	req := map[string]interface{}{"path": path, "dacl": acl, "acltype": ACLTypePOSIX1E, "options": opts}
+	return f.setACL(ctx, req, uid, gid, callback)
+}
+
+// SetACL writes acl back to its path, e.g. after modifying the result of GetACL.
+func (f *Filesystem) SetACL(ctx context.Context, acl *FileACL, opts SetACLOptions, callback JobCallback) (*Job, error) {
+	uid, gid := &acl.UID, &acl.GID
+	switch acl.ACLType {
+	case ACLTypeNFS4:
+		return f.SetNFS4ACL(ctx, acl.Path, acl.NFS4, acl.NFS4Flags, uid, gid, opts, callback)
+	case ACLTypePOSIX1E:
+		return f.SetPOSIXACL(ctx, acl.Path, acl.POSIX, uid, gid, opts, callback)
+	}
+	return nil, fmt.Errorf("can't set an ACL of type %q on %s", acl.ACLType, acl.Path)
+}
+
+// setACL calls filesystem.setacl.
+func (f *Filesystem) setACL(ctx context.Context, req map[string]interface{}, uid, gid *int, callback JobCallback) (*Job, error) {
+	if uid != nil {
+		req["uid"] = *uid
+	}
+	if gid != nil {
+		req["gid"] = *gid
+	}
+	return f.client.callJob(ctx, "filesystem.setacl", []interface{}{req}, callback)
+}
+
+// SetPerm sets the mode, e.g. "755", and optionally the owner of a path. An
+// empty mode leaves it unchanged, a nil uid or gid leaves the owner unchanged.
+// Setting a mode on a path with a non-trivial ACL requires opts.StripACL.
+func (f *Filesystem) SetPerm(ctx context.Context, path, mode string, uid, gid *int, opts PermOptions, callback JobCallback) (*Job, error) {
+	req := map[string]interface{}{"path": path, "options": opts}
+	if mode != "" {
+		req["mode"] = mode
+	}
+	if uid != nil {
+		req["uid"] = *uid
+	}
+	if gid != nil {
+		req["gid"] = *gid
+	}
+	return f.client.callJob(ctx, "filesystem.setperm", []interface{}{req}, callback)
+}
+
+// Chown changes the owner of a path by user and group name. An empty user
+// or group leaves it unchanged.
+func (f *Filesystem) Chown(ctx context.Context, path, user, group string, opts PermOptions, callback JobCallback) (*Job, error) {
+	opts.StripACL = false // Not accepted by filesystem.chown
+	req := map[string]interface{}{"path": path, "options": opts}
+	if user != "" {
+		req["user"] = user
+	}
+	if group != "" {
+		req["group"] = group
+	}
+	return f.client.callJob(ctx, "filesystem.chown", []interface{}{req}, callback)
+}
@@ -0,0 +1,117 @@
+package truenas_api
+
+import (
+	"context"
+	"fmt"
+)
+
+// Init/shutdown script trigger points.
+const (
+	InitScriptPreInit  = "PREINIT"
+	InitScriptPostInit = "POSTINIT"
+	InitScriptShutdown = "SHUTDOWN"
+)
+
+// InitShutdownScript is an init/shutdown script as returned by initshutdownscript.query.
+type InitShutdownScript struct {
+	ID      int    `json:"id"`
+	Type    string `json:"type"`    // "COMMAND" or "SCRIPT"
+	Command string `json:"command"` // Command line, for type COMMAND
+	Script  string `json:"script"`  // Path of the script, for type SCRIPT
+	When    string `json:"when"`    // One of the InitScript* trigger points
+	Enabled bool   `json:"enabled"`
+	Timeout int    `json:"timeout"` // Seconds
+	Comment string `json:"comment"`
+}
+
+// InitShutdownScriptRequest holds the writable fields of an init/shutdown script.
+type InitShutdownScriptRequest struct {
+	Type    string `json:"type"`
+	Command string `json:"command,omitempty"`
+	Script  string `json:"script,omitempty"`
+	When    string `json:"when"`
+	Enabled bool   `json:"enabled"`
+	Timeout int    `json:"timeout,omitempty"`
+	Comment string `json:"comment"`
+}
+
+// Request returns the writable fields of the script, to be modified and passed to Update.
+func (s InitShutdownScript) Request() InitShutdownScriptRequest {
+	return InitShutdownScriptRequest{
+		Type:    s.Type,
+		Command: s.Command,
+		Script:  s.Script,
+		When:    s.When,
+		Enabled: s.Enabled,
+		Timeout: s.Timeout,
+		Comment: s.Comment,
+	}
+}
+
+// validate checks that the fields required by the script type are set.
+func (r InitShutdownScriptRequest) validate() error {
+	switch {
+	case r.Type == "COMMAND" && r.Command == "":
+		return fmt.Errorf("a COMMAND init/shutdown script requires a command")
+	case r.Type == "SCRIPT" && r.Script == "":
+		return fmt.Errorf("a SCRIPT init/shutdown script requires a script path")
+	case r.Type != "COMMAND" && r.Type != "SCRIPT":
+		return fmt.Errorf("invalid init/shutdown script type %q", r.Type)
+	}
+	return nil
+}
+
+// InitShutdownScripts provides typed access to the initshutdownscript.* namespace.
+type InitShutdownScripts struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewInitShutdownScripts creates a new InitShutdownScripts service.
+func NewInitShutdownScripts(client *Client) *InitShutdownScripts {
+	return &InitShutdownScripts{client: client}
+}
+
+// Query returns the init/shutdown scripts matching filters.
+func (s *InitShutdownScripts) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]InitShutdownScript, error) {
+	var scripts []InitShutdownScript
+	err := s.client.callResult(ctx, "initshutdownscript.query", queryParams(filters, opts), &scripts)
+	return scripts, err
+}
+
+// Get returns a single init/shutdown script by ID.
+func (s *InitShutdownScripts) Get(ctx context.Context, id int) (*InitShutdownScript, error) {
+	var script InitShutdownScript
+	if err := s.client.callResult(ctx, "initshutdownscript.get_instance", []interface{}{id}, &script); err != nil {
+		return nil, err
+	}
+	return &script, nil
+}
+
+// Create creates an init/shutdown script.
+func (s *InitShutdownScripts) Create(ctx context.Context, req InitShutdownScriptRequest) (*InitShutdownScript, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	var script InitShutdownScript
+	if err := s.client.callResult(ctx, "initshutdownscript.create", []interface{}{req}, &script); err != nil {
+		return nil, err
+	}
+	return &script, nil
+}
+
+// Update replaces the writable fields of an init/shutdown script.
+func (s *InitShutdownScripts) Update(ctx context.Context, id int, req InitShutdownScriptRequest) (*InitShutdownScript, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	var script InitShutdownScript
+	if err := s.client.callResult(ctx, "initshutdownscript.update", []interface{}{id, req}, &script); err != nil {
+		return nil, err
+	}
+	return &script, nil
+}
+
+// Delete deletes an init/shutdown script.
+func (s *InitShutdownScripts) Delete(ctx context.Context, id int) error {
+	return s.client.callResult(ctx, "initshutdownscript.delete", []interface{}{id}, nil)
+}
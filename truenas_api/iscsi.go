@@ -0,0 +1,379 @@
+package truenas_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ISCSIPortal is an iSCSI portal as returned by iscsi.portal.query.
+type ISCSIPortal struct {
+	ID      int                 `json:"id"`
+	Tag     int                 `json:"tag"`
+	Comment string              `json:"comment"`
+	Listen  []ISCSIPortalListen `json:"listen"`
+}
+
+// ISCSIPortalListen is an address a portal listens on.
+type ISCSIPortalListen struct {
+	IP   string `json:"ip"`
+	Port int    `json:"port,omitempty"` // Set by the server
+}
+
+// ISCSIPortalRequest holds the writable fields of a portal.
+type ISCSIPortalRequest struct {
+	Comment string              `json:"comment"`
+	Listen  []ISCSIPortalListen `json:"listen"`
+}
+
+// ISCSIInitiator is an authorized initiator group as returned by iscsi.initiator.query.
+type ISCSIInitiator struct {
+	ID         int      `json:"id"`
+	Initiators []string `json:"initiators"` // Initiator IQNs, empty allows all initiators
+	Comment    string   `json:"comment"`
+}
+
+// ISCSIInitiatorRequest holds the writable fields of an initiator group.
+type ISCSIInitiatorRequest struct {
+	Initiators []string `json:"initiators"`
+	Comment    string   `json:"comment"`
+}
+
+// ISCSITarget is an iSCSI target as returned by iscsi.target.query.
+type ISCSITarget struct {
+	ID           int                `json:"id"`
+	Name         string             `json:"name"` // Appended to the global base name to form the IQN
+	Alias        string             `json:"alias"`
+	Mode         string             `json:"mode"` // "ISCSI", "FC" or "BOTH"
+	Groups       []ISCSITargetGroup `json:"groups"`
+	AuthNetworks []string           `json:"auth_networks"`
+}
+
+// ISCSITargetGroup associates a target with a portal, initiator group and authentication method.
+type ISCSITargetGroup struct {
+	Portal     int    `json:"portal"`
+	Initiator  *int   `json:"initiator"`            // nil allows all initiators
+	AuthMethod string `json:"authmethod,omitempty"` // "NONE", "CHAP" or "CHAP_MUTUAL"
+	Auth       *int   `json:"auth"`                 // Authorized access tag for CHAP
+}
+
+// ISCSITargetRequest holds the writable fields of a target.
+type ISCSITargetRequest struct {
+	Name         string             `json:"name"`
+	Alias        string             `json:"alias,omitempty"`
+	Mode         string             `json:"mode,omitempty"` // Defaults to "ISCSI"
+	Groups       []ISCSITargetGroup `json:"groups"`
+	AuthNetworks []string           `json:"auth_networks"`
+}
+
+// ISCSIExtent is an iSCSI extent as returned by iscsi.extent.query.
+type ISCSIExtent struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	Type           string `json:"type"` // "DISK" or "FILE"
+	Disk           string `json:"disk"` // "zvol/<dataset>" for DISK extents
+	Path           string `json:"path"` // Backing file for FILE extents
+	Filesize       int64  `json:"filesize"`
+	Serial         string `json:"serial"`
+	Blocksize      int    `json:"blocksize"`
+	PBlocksize     bool   `json:"pblocksize"`
+	AvailThreshold *int   `json:"avail_threshold"`
+	Comment        string `json:"comment"`
+	NAA            string `json:"naa"`
+	InsecureTPC    bool   `json:"insecure_tpc"`
+	Xen            bool   `json:"xen"`
+	RPM            string `json:"rpm"`
+	ReadOnly       bool   `json:"ro"`
+	Enabled        bool   `json:"enabled"`
+	Vendor         string `json:"vendor"`
+	Locked         bool   `json:"locked"`
+}
+
+// ISCSIExtentRequest holds the writable fields of an extent.
+type ISCSIExtentRequest struct {
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Disk           string `json:"disk,omitempty"`
+	Path           string `json:"path,omitempty"`
+	Filesize       int64  `json:"filesize,omitempty"`
+	Serial         string `json:"serial,omitempty"` // Generated by the server if empty
+	Blocksize      int    `json:"blocksize,omitempty"`
+	PBlocksize     bool   `json:"pblocksize"`
+	AvailThreshold *int   `json:"avail_threshold"`
+	Comment        string `json:"comment"`
+	InsecureTPC    bool   `json:"insecure_tpc"`
+	Xen            bool   `json:"xen"`
+	RPM            string `json:"rpm,omitempty"`
+	ReadOnly       bool   `json:"ro"`
+	Enabled        bool   `json:"enabled"`
+}
+
+// ISCSITargetExtent associates an extent with a target as a LUN.
+type ISCSITargetExtent struct {
+	ID     int `json:"id"`
+	Target int `json:"target"`
+	LUNID  int `json:"lunid"`
+	Extent int `json:"extent"`
+}
+
+// ISCSITargetExtentRequest holds the writable fields of a target/extent association.
+type ISCSITargetExtentRequest struct {
+	Target int  `json:"target"`
+	LUNID  *int `json:"lunid"` // nil picks the next free LUN ID
+	Extent int  `json:"extent"`
+}
+
+// LUNSpec describes a zvol backed LUN to provision with ISCSI.ProvisionLUN.
+type LUNSpec struct {
+	Name         string // Name of the extent and target
+	Zvol         string // Dataset name of the zvol to create, e.g. "tank/iscsi/lun0"
+	VolSize      int64  // Size of the zvol in bytes
+	VolBlockSize string // zvol block size, e.g. "16K", empty for the default
+	Sparse       bool   // Create a thin provisioned zvol
+	PortalID     int    // Portal the target is reachable on
+	InitiatorID  *int   // Initiator group allowed to connect, nil allows all
+	LUNID        *int   // LUN ID of the extent, nil picks the next free ID
+	Blocksize    int    // Logical block size of the extent, 0 for the default
+}
+
+// LUN holds the objects created by ISCSI.ProvisionLUN.
+type LUN struct {
+	Zvol         string
+	Extent       *ISCSIExtent
+	Target       *ISCSITarget
+	TargetExtent *ISCSITargetExtent
+}
+
+// ISCSI provides typed access to the iscsi.portal, iscsi.initiator, iscsi.target,
+// iscsi.extent and iscsi.targetextent namespaces.
+type ISCSI struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewISCSI creates a new ISCSI service.
+func NewISCSI(client *Client) *ISCSI {
+	return &ISCSI{client: client}
+}
+
+// QueryPortals returns the portals matching filters.
+func (s *ISCSI) QueryPortals(ctx context.Context, filters []Filter, opts *QueryOptions) ([]ISCSIPortal, error) {
+	var portals []ISCSIPortal
+	err := s.client.callResult(ctx, "iscsi.portal.query", queryParams(filters, opts), &portals)
+	return portals, err
+}
+
+// CreatePortal creates a portal.
+func (s *ISCSI) CreatePortal(ctx context.Context, req ISCSIPortalRequest) (*ISCSIPortal, error) {
+	var portal ISCSIPortal
+	if err := s.client.callResult(ctx, "iscsi.portal.create", []interface{}{req}, &portal); err != nil {
+		return nil, err
+	}
+	return &portal, nil
+}
+
+// UpdatePortal replaces the writable fields of a portal.
+func (s *ISCSI) UpdatePortal(ctx context.Context, id int, req ISCSIPortalRequest) (*ISCSIPortal, error) {
+	var portal ISCSIPortal
+	if err := s.client.callResult(ctx, "iscsi.portal.update", []interface{}{id, req}, &portal); err != nil {
+		return nil, err
+	}
+	return &portal, nil
+}
+
+// DeletePortal deletes a portal.
+func (s *ISCSI) DeletePortal(ctx context.Context, id int) error {
+	return s.client.callResult(ctx, "iscsi.portal.delete", []interface{}{id}, nil)
+}
+
+// QueryInitiators returns the initiator groups matching filters.
+func (s *ISCSI) QueryInitiators(ctx context.Context, filters []Filter, opts *QueryOptions) ([]ISCSIInitiator, error) {
+	var initiators []ISCSIInitiator
+	err := s.client.callResult(ctx, "iscsi.initiator.query", queryParams(filters, opts), &initiators)
+	return initiators, err
+}
+
+// CreateInitiator creates an initiator group.
+func (s *ISCSI) CreateInitiator(ctx context.Context, req ISCSIInitiatorRequest) (*ISCSIInitiator, error) {
+	var initiator ISCSIInitiator
+	if err := s.client.callResult(ctx, "iscsi.initiator.create", []interface{}{req}, &initiator); err != nil {
+		return nil, err
+	}
+	return &initiator, nil
+}
+
+// UpdateInitiator replaces the writable fields of an initiator group.
+func (s *ISCSI) UpdateInitiator(ctx context.Context, id int, req ISCSIInitiatorRequest) (*ISCSIInitiator, error) {
+	var initiator ISCSIInitiator
+	if err := s.client.callResult(ctx, "iscsi.initiator.update", []interface{}{id, req}, &initiator); err != nil {
+		return nil, err
+	}
+	return &initiator, nil
+}
+
+// DeleteInitiator deletes an initiator group.
+func (s *ISCSI) DeleteInitiator(ctx context.Context, id int) error {
+	return s.client.callResult(ctx, "iscsi.initiator.delete", []interface{}{id}, nil)
+}
+
+// QueryTargets returns the targets matching filters.
+func (s *ISCSI) QueryTargets(ctx context.Context, filters []Filter, opts *QueryOptions) ([]ISCSITarget, error) {
+	var targets []ISCSITarget
+	err := s.client.callResult(ctx, "iscsi.target.query", queryParams(filters, opts), &targets)
+	return targets, err
+}
+
+// CreateTarget creates a target.
+func (s *ISCSI) CreateTarget(ctx context.Context, req ISCSITargetRequest) (*ISCSITarget, error) {
+	var target ISCSITarget
+	if err := s.client.callResult(ctx, "iscsi.target.create", []interface{}{req}, &target); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// UpdateTarget replaces the writable fields of a target.
+func (s *ISCSI) UpdateTarget(ctx context.Context, id int, req ISCSITargetRequest) (*ISCSITarget, error) {
+	var target ISCSITarget
+	if err := s.client.callResult(ctx, "iscsi.target.update", []interface{}{id, req}, &target); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// DeleteTarget deletes a target. force deletes it even if it is in use by an initiator.
+func (s *ISCSI) DeleteTarget(ctx context.Context, id int, force bool) error {
+	return s.client.callResult(ctx, "iscsi.target.delete", []interface{}{id, force}, nil)
+}
+
+// QueryExtents returns the extents matching filters.
+func (s *ISCSI) QueryExtents(ctx context.Context, filters []Filter, opts *QueryOptions) ([]ISCSIExtent, error) {
+	var extents []ISCSIExtent
+	err := s.client.callResult(ctx, "iscsi.extent.query", queryParams(filters, opts), &extents)
+	return extents, err
+}
+
+// CreateExtent creates an extent.
+func (s *ISCSI) CreateExtent(ctx context.Context, req ISCSIExtentRequest) (*ISCSIExtent, error) {
+	var extent ISCSIExtent
+	if err := s.client.callResult(ctx, "iscsi.extent.create", []interface{}{req}, &extent); err != nil {
+		return nil, err
+	}
+	return &extent, nil
+}
+
+// UpdateExtent replaces the writable fields of an extent.
+func (s *ISCSI) UpdateExtent(ctx context.Context, id int, req ISCSIExtentRequest) (*ISCSIExtent, error) {
+	var extent ISCSIExtent
+	if err := s.client.callResult(ctx, "iscsi.extent.update", []interface{}{id, req}, &extent); err != nil {
+		return nil, err
+	}
+	return &extent, nil
+}
+
+// DeleteExtent deletes an extent. remove also deletes the backing file of FILE extents,
+// force deletes it even if it is in use.
+func (s *ISCSI) DeleteExtent(ctx context.Context, id int, remove, force bool) error {
+	return s.client.callResult(ctx, "iscsi.extent.delete", []interface{}{id, remove, force}, nil)
+}
+
+// QueryTargetExtents returns the target/extent associations matching filters.
+func (s *ISCSI) QueryTargetExtents(ctx context.Context, filters []Filter, opts *QueryOptions) ([]ISCSITargetExtent, error) {
+	var associations []ISCSITargetExtent
+	err := s.client.callResult(ctx, "iscsi.targetextent.query", queryParams(filters, opts), &associations)
+	return associations, err
+}
+
+// CreateTargetExtent associates an extent with a target.
+func (s *ISCSI) CreateTargetExtent(ctx context.Context, req ISCSITargetExtentRequest) (*ISCSITargetExtent, error) {
+	var association ISCSITargetExtent
+	if err := s.client.callResult(ctx, "iscsi.targetextent.create", []interface{}{req}, &association); err != nil {
+		return nil, err
+	}
+	return &association, nil
+}
+
+// DeleteTargetExtent deletes a target/extent association. force deletes it even if it is in use.
+func (s *ISCSI) DeleteTargetExtent(ctx context.Context, id int, force bool) error {
+	return s.client.callResult(ctx, "iscsi.targetextent.delete", []interface{}{id, force}, nil)
+}
+
+// ProvisionLUN creates a zvol and exports it as a LUN of a new target: it creates
+// the zvol, an extent on it, a target on spec.PortalID and the association between
+// them. If any step fails, the objects created so far are deleted in reverse order
+// and the returned error includes any cleanup failures.
+func (s *ISCSI) ProvisionLUN(ctx context.Context, spec LUNSpec) (lun *LUN, err error) {
+	var rollback []func(ctx context.Context) error
+	defer func() {
+		if err == nil {
+			return
+		}
+		// Clean up even if ctx was cancelled, but don't hang on an unresponsive server
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+		defer cancel()
+		for i := len(rollback) - 1; i >= 0; i-- {
+			if rbErr := rollback[i](cleanupCtx); rbErr != nil {
+				err = errors.Join(err, fmt.Errorf("rollback failed: %w", rbErr))
+			}
+		}
+	}()
+
+	zvol := map[string]interface{}{
+		"name":    spec.Zvol,
+		"type":    "VOLUME",
+		"volsize": spec.VolSize,
+		"sparse":  spec.Sparse,
+	}
+	if spec.VolBlockSize != "" {
+		zvol["volblocksize"] = spec.VolBlockSize
+	}
+	if err := s.client.callResult(ctx, "pool.dataset.create", []interface{}{zvol}, nil); err != nil {
+		return nil, fmt.Errorf("failed to create zvol %s: %w", spec.Zvol, err)
+	}
+	rollback = append(rollback, func(ctx context.Context) error {
+		opts := map[string]interface{}{"recursive": false, "force": true}
+		return s.client.callResult(ctx, "pool.dataset.delete", []interface{}{spec.Zvol, opts}, nil)
+	})
+
+	extent, err := s.CreateExtent(ctx, ISCSIExtentRequest{
+		Name:      spec.Name,
+		Type:      "DISK",
+		Disk:      "zvol/" + spec.Zvol,
+		Blocksize: spec.Blocksize,
+		Enabled:   true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create extent %s: %w", spec.Name, err)
+	}
+	rollback = append(rollback, func(ctx context.Context) error {
+		return s.DeleteExtent(ctx, extent.ID, false, true)
+	})
+
+	target, err := s.CreateTarget(ctx, ISCSITargetRequest{
+		Name:         spec.Name,
+		Groups:       []ISCSITargetGroup{{Portal: spec.PortalID, Initiator: spec.InitiatorID, AuthMethod: "NONE"}},
+		AuthNetworks: []string{},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create target %s: %w", spec.Name, err)
+	}
+	rollback = append(rollback, func(ctx context.Context) error {
+		return s.DeleteTarget(ctx, target.ID, true)
+	})
+
+	association, err := s.CreateTargetExtent(ctx, ISCSITargetExtentRequest{
+		Target: target.ID,
+		LUNID:  spec.LUNID,
+		Extent: extent.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to associate extent with target: %w", err)
+	}
+
+	return &LUN{
+		Zvol:         spec.Zvol,
+		Extent:       extent,
+		Target:       target,
+		TargetExtent: association,
+	}, nil
+}
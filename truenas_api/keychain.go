@@ -0,0 +1,248 @@
+package truenas_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Keychain credential types.
+const (
+	KeychainSSHKeyPair     = "SSH_KEY_PAIR"
+	KeychainSSHCredentials = "SSH_CREDENTIALS"
+)
+
+// KeychainCredential is a keychain credential as returned by keychaincredential.query.
+type KeychainCredential struct {
+	ID         int             `json:"id"`
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`       // One of the Keychain* types
+	Attributes json.RawMessage `json:"attributes"` // See SSHKeyPair and SSHCredentials
+}
+
+// SSHKeyPair holds the attributes of an SSH_KEY_PAIR credential.
+type SSHKeyPair struct {
+	PrivateKey string `json:"private_key,omitempty"`
+	PublicKey  string `json:"public_key"`
+}
+
+// SSHCredentials holds the attributes of an SSH_CREDENTIALS credential, an SSH connection.
+type SSHCredentials struct {
+	Host           string `json:"host"`
+	Port           int    `json:"port"`
+	Username       string `json:"username"`
+	PrivateKey     int    `json:"private_key"` // ID of the SSH_KEY_PAIR credential
+	RemoteHostKey  string `json:"remote_host_key"`
+	ConnectTimeout int    `json:"connect_timeout"` // Seconds
+}
+
+// SSHKeyPair decodes the attributes of an SSH_KEY_PAIR credential.
+func (k KeychainCredential) SSHKeyPair() (*SSHKeyPair, error) {
+	if k.Type != KeychainSSHKeyPair {
+		return nil, fmt.Errorf("credential %s is of type %s, not %s", k.Name, k.Type, KeychainSSHKeyPair)
+	}
+	var pair SSHKeyPair
+	if err := json.Unmarshal(k.Attributes, &pair); err != nil {
+		return nil, fmt.Errorf("failed to parse %s attributes: %w", k.Type, err)
+	}
+	return &pair, nil
+}
+
+// SSHCredentials decodes the attributes of an SSH_CREDENTIALS credential.
+func (k KeychainCredential) SSHCredentials() (*SSHCredentials, error) {
+	if k.Type != KeychainSSHCredentials {
+		return nil, fmt.Errorf("credential %s is of type %s, not %s", k.Name, k.Type, KeychainSSHCredentials)
+	}
+	var creds SSHCredentials
+	if err := json.Unmarshal(k.Attributes, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse %s attributes: %w", k.Type, err)
+	}
+	return &creds, nil
+}
+
+// KeychainCredentialUsage is a task or service that uses a keychain credential.
+type KeychainCredentialUsage struct {
+	Title        string `json:"title"`
+	UnbindMethod string `json:"unbind_method"` // "delete" or "disable", applied by a cascading delete
+}
+
+// SSHSetupRequest holds the fields of keychaincredential.remote_ssh_semiautomatic_setup.
+// Authenticate to the remote system with either Token or AdminUsername and Password.
+type SSHSetupRequest struct {
+	Name           string `json:"name"`
+	URL            string `json:"url"` // Remote system as reached from this system, e.g. "https://nas2"
+	VerifySSL      bool   `json:"verify_ssl"`
+	Token          string `json:"token,omitempty"`
+	AdminUsername  string `json:"admin_username,omitempty"`
+	Password       string `json:"password,omitempty"`
+	OTPToken       string `json:"otp_token,omitempty"`
+	Username       string `json:"username"`    // Remote user to connect as
+	PrivateKey     int    `json:"private_key"` // ID of the SSH_KEY_PAIR credential
+	ConnectTimeout int    `json:"connect_timeout,omitempty"`
+	Sudo           bool   `json:"sudo"` // Use sudo for zfs commands on the remote system
+}
+
+// Keychain provides typed access to the keychaincredential.* namespace.
+type Keychain struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewKeychain creates a new Keychain service.
+func NewKeychain(client *Client) *Keychain {
+	return &Keychain{client: client}
+}
+
+// Query returns the keychain credentials matching filters.
+func (k *Keychain) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]KeychainCredential, error) {
+	var creds []KeychainCredential
+	err := k.client.callResult(ctx, "keychaincredential.query", queryParams(filters, opts), &creds)
+	return creds, err
+}
+
+// Get returns a keychain credential by ID.
+func (k *Keychain) Get(ctx context.Context, id int) (*KeychainCredential, error) {
+	var cred KeychainCredential
+	if err := k.client.callResult(ctx, "keychaincredential.get_instance", []interface{}{id}, &cred); err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// create creates a keychain credential with the given attributes.
+func (k *Keychain) create(ctx context.Context, name, credType string, attributes interface{}) (*KeychainCredential, error) {
+	req := map[string]interface{}{"name": name, "type": credType, "attributes": attributes}
+	var cred KeychainCredential
+	if err := k.client.callResult(ctx, "keychaincredential.create", []interface{}{req}, &cred); err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// GenerateSSHKeyPair generates a new SSH key pair without storing it.
+func (k *Keychain) GenerateSSHKeyPair(ctx context.Context) (*SSHKeyPair, error) {
+	var pair SSHKeyPair
+	if err := k.client.callResult(ctx, "keychaincredential.generate_ssh_key_pair", nil, &pair); err != nil {
+		return nil, err
+	}
+	return &pair, nil
+}
+
+// CreateSSHKeyPair stores an SSH key pair. A nil pair generates a new one.
+func (k *Keychain) CreateSSHKeyPair(ctx context.Context, name string, pair *SSHKeyPair) (*KeychainCredential, error) {
+	if pair == nil {
+		var err error
+		if pair, err = k.GenerateSSHKeyPair(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return k.create(ctx, name, KeychainSSHKeyPair, pair)
+}
+
+// CreateSSHCredentials stores an SSH connection.
+func (k *Keychain) CreateSSHCredentials(ctx context.Context, name string, creds SSHCredentials) (*KeychainCredential, error) {
+	return k.create(ctx, name, KeychainSSHCredentials, creds)
+}
+
+// Rename renames a keychain credential, keeping its attributes.
+func (k *Keychain) Rename(ctx context.Context, id int, name string) (*KeychainCredential, error) {
+	var cred KeychainCredential
+	if err := k.client.callResult(ctx, "keychaincredential.update", []interface{}{id, map[string]interface{}{"name": name}}, &cred); err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// UsedBy returns the tasks and services that use a keychain credential.
+func (k *Keychain) UsedBy(ctx context.Context, id int) ([]KeychainCredentialUsage, error) {
+	var usages []KeychainCredentialUsage
+	err := k.client.callResult(ctx, "keychaincredential.used_by", []interface{}{id}, &usages)
+	return usages, err
+}
+
+// Delete deletes a keychain credential. Unless cascade is set, a credential
+// still in use is not deleted and an error listing its users is returned;
+// with cascade set the users are deleted or disabled along with it.
+func (k *Keychain) Delete(ctx context.Context, id int, cascade bool) error {
+	if !cascade {
+		usages, err := k.UsedBy(ctx, id)
+		if err != nil {
+			return err
+		}
+		if len(usages) > 0 {
+			titles := make([]string, 0, len(usages))
+			for _, usage := range usages {
+				titles = append(titles, usage.Title)
+			}
+			return fmt.Errorf("keychain credential %d is used by %s", id, strings.Join(titles, ", "))
+		}
+	}
+	return k.client.callResult(ctx, "keychaincredential.delete", []interface{}{id, map[string]interface{}{"cascade": cascade}}, nil)
+}
+
+// ScanHostKey returns the SSH host key of host, for SSHCredentials.RemoteHostKey.
+func (k *Keychain) ScanHostKey(ctx context.Context, host string, port, connectTimeout int) (string, error) {
+	req := map[string]interface{}{"host": host, "port": port, "connect_timeout": connectTimeout}
+	var key string
+	err := k.client.callResult(ctx, "keychaincredential.remote_ssh_host_key_scan", []interface{}{req}, &key)
+	return key, err
+}
+
+// SemiAutomaticSetup has this system authorize its SSH key pair on a remote
+// TrueNAS system and stores the resulting SSH connection.
+func (k *Keychain) SemiAutomaticSetup(ctx context.Context, req SSHSetupRequest) (*KeychainCredential, error) {
+	var cred KeychainCredential
+	if err := k.client.callResult(ctx, "keychaincredential.remote_ssh_semiautomatic_setup", []interface{}{req}, &cred); err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+// SetupSSHConnection creates an SSH connection called name from the system
+// of k to the system of remote, e.g. for replication. It generates a key pair
+// on this system and authorizes it for username on the remote system, using a
+// short-lived token from the remote client instead of admin credentials.
+// remoteURL is the remote system as reached from this system; if empty it is
+// derived from the remote client's URL. On failure the key pair is removed again.
+func (k *Keychain) SetupSSHConnection(ctx context.Context, remote *Client, name, username, remoteURL string, sudo bool) (*KeychainCredential, error) {
+	if remoteURL == "" {
+		base, err := remote.httpURL("/")
+		if err != nil {
+			return nil, err
+		}
+		remoteURL = strings.TrimSuffix(base, "/")
+	}
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote URL: %w", err)
+	}
+
+	var token string
+	if err := remote.callResult(ctx, "auth.generate_token", []interface{}{300, map[string]interface{}{}, false}, &token); err != nil {
+		return nil, fmt.Errorf("failed to get a token from %s: %w", u.Host, err)
+	}
+
+	pair, err := k.CreateSSHKeyPair(ctx, name+" key", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := k.SemiAutomaticSetup(ctx, SSHSetupRequest{
+		Name:       name,
+		URL:        remoteURL,
+		VerifySSL:  remote.verifySSL,
+		Token:      token,
+		Username:   username,
+		PrivateKey: pair.ID,
+		Sudo:       sudo,
+	})
+	if err != nil {
+		if delErr := k.Delete(context.WithoutCancel(ctx), pair.ID, false); delErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("failed to remove key pair %d: %w", pair.ID, delErr))
+		}
+		return nil, err
+	}
+	return cred, nil
+}
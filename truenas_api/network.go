@@ -0,0 +1,511 @@
+package truenas_api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// NetworkInterface is a network interface as returned by interface.query.
+type NetworkInterface struct {
+	ID                  string                `json:"id"`
+	Name                string                `json:"name"`
+	Fake                bool                  `json:"fake"`
+	Type                string                `json:"type"` // "PHYSICAL", "BRIDGE", "LINK_AGGREGATION" or "VLAN"
+	State               NetworkInterfaceState `json:"state"`
+	Aliases             []InterfaceAlias      `json:"aliases"` // Configured aliases, including uncommitted changes
+	IPv4DHCP            bool                  `json:"ipv4_dhcp"`
+	IPv6Auto            bool                  `json:"ipv6_auto"`
+	Description         string                `json:"description"`
+	MTU                 *int                  `json:"mtu"`
+	VLANParentInterface string                `json:"vlan_parent_interface,omitempty"`
+	VLANTag             int                   `json:"vlan_tag,omitempty"`
+	VLANPCP             *int                  `json:"vlan_pcp,omitempty"`
+	LAGProtocol         string                `json:"lag_protocol,omitempty"`
+	LAGPorts            []string              `json:"lag_ports,omitempty"`
+	BridgeMembers       []string              `json:"bridge_members,omitempty"`
+	EnableLearning      bool                  `json:"enable_learning,omitempty"`
+}
+
+// NetworkInterfaceState is the live state of a network interface.
+type NetworkInterfaceState struct {
+	Name               string           `json:"name"`
+	LinkState          string           `json:"link_state"` // e.g. "LINK_STATE_UP"
+	ActiveMediaType    string           `json:"active_media_type"`
+	ActiveMediaSubtype string           `json:"active_media_subtype"`
+	LinkAddress        string           `json:"link_address"` // MAC address
+	MTU                int              `json:"mtu"`
+	Aliases            []InterfaceAlias `json:"aliases"` // Addresses currently in use
+}
+
+// InterfaceAlias is an address of a network interface.
+type InterfaceAlias struct {
+	Type    string `json:"type"` // "INET", "INET6" or, in the live state, "LINK"
+	Address string `json:"address"`
+	Netmask int    `json:"netmask"` // Prefix length
+}
+
+// NetworkInterfaceRequest holds the writable fields of a network interface.
+// Name and Type are only used on create, the VLAN, LAG and bridge fields only
+// apply to interfaces of that type.
+type NetworkInterfaceRequest struct {
+	Name                string           `json:"name,omitempty"`
+	Type                string           `json:"type,omitempty"`
+	Description         string           `json:"description"`
+	IPv4DHCP            bool             `json:"ipv4_dhcp"`
+	IPv6Auto            bool             `json:"ipv6_auto"`
+	Aliases             []InterfaceAlias `json:"aliases"`
+	MTU                 *int             `json:"mtu,omitempty"`
+	VLANParentInterface string           `json:"vlan_parent_interface,omitempty"`
+	VLANTag             int              `json:"vlan_tag,omitempty"`
+	VLANPCP             *int             `json:"vlan_pcp,omitempty"`
+	LAGProtocol         string           `json:"lag_protocol,omitempty"`
+	LAGPorts            []string         `json:"lag_ports,omitempty"`
+	BridgeMembers       []string         `json:"bridge_members,omitempty"`
+}
+
+// Request returns the writable fields of the interface, for use with Network.UpdateInterface.
+func (i NetworkInterface) Request() NetworkInterfaceRequest {
+	aliases := i.Aliases
+	if aliases == nil {
+		aliases = []InterfaceAlias{}
+	}
+	return NetworkInterfaceRequest{
+		Description:         i.Description,
+		IPv4DHCP:            i.IPv4DHCP,
+		IPv6Auto:            i.IPv6Auto,
+		Aliases:             aliases,
+		MTU:                 i.MTU,
+		VLANParentInterface: i.VLANParentInterface,
+		VLANTag:             i.VLANTag,
+		VLANPCP:             i.VLANPCP,
+		LAGProtocol:         i.LAGProtocol,
+		LAGPorts:            i.LAGPorts,
+		BridgeMembers:       i.BridgeMembers,
+	}
+}
+
+// InterfaceChange is a single change applied by Network.ApplyWithCheckin.
+// An empty ID creates an interface from Request, a nil Request deletes the
+// interface with ID, and otherwise the interface with ID is updated.
+type InterfaceChange struct {
+	ID      string
+	Request *NetworkInterfaceRequest
+}
+
+// StaticRoute is a static route as returned by staticroute.query.
+type StaticRoute struct {
+	ID          int    `json:"id"`
+	Destination string `json:"destination"` // CIDR, e.g. "10.0.0.0/8"
+	Gateway     string `json:"gateway"`
+	Description string `json:"description"`
+}
+
+// StaticRouteRequest holds the writable fields of a static route.
+type StaticRouteRequest struct {
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway"`
+	Description string `json:"description"`
+}
+
+// NetworkConfig is the global network configuration (network.configuration.config).
+type NetworkConfig struct {
+	ID                  int                 `json:"id"`
+	Hostname            string              `json:"hostname"`
+	HostnameLocal       string              `json:"hostname_local"`
+	Domain              string              `json:"domain"`
+	Domains             []string            `json:"domains"` // Additional search domains
+	IPv4Gateway         string              `json:"ipv4gateway"`
+	IPv6Gateway         string              `json:"ipv6gateway"`
+	Nameserver1         string              `json:"nameserver1"`
+	Nameserver2         string              `json:"nameserver2"`
+	Nameserver3         string              `json:"nameserver3"`
+	HTTPProxy           string              `json:"httpproxy"`
+	Hosts               []string            `json:"hosts"` // Additional /etc/hosts entries
+	ServiceAnnouncement ServiceAnnouncement `json:"service_announcement"`
+}
+
+// ServiceAnnouncement selects the discovery protocols the NAS announces itself with.
+type ServiceAnnouncement struct {
+	NetBIOS bool `json:"netbios"`
+	MDNS    bool `json:"mdns"`
+	WSD     bool `json:"wsd"`
+}
+
+// NetworkConfigRequest holds the writable fields of the global network configuration.
+type NetworkConfigRequest struct {
+	Hostname            string              `json:"hostname"`
+	Domain              string              `json:"domain"`
+	Domains             []string            `json:"domains"`
+	IPv4Gateway         string              `json:"ipv4gateway"`
+	IPv6Gateway         string              `json:"ipv6gateway"`
+	Nameserver1         string              `json:"nameserver1"`
+	Nameserver2         string              `json:"nameserver2"`
+	Nameserver3         string              `json:"nameserver3"`
+	HTTPProxy           string              `json:"httpproxy"`
+	Hosts               []string            `json:"hosts"`
+	ServiceAnnouncement ServiceAnnouncement `json:"service_announcement"`
+}
+
+// Request returns the writable fields of the configuration, for use with Network.UpdateConfig.
+func (n NetworkConfig) Request() NetworkConfigRequest {
+	return NetworkConfigRequest{
+		Hostname:            n.Hostname,
+		Domain:              n.Domain,
+		Domains:             n.Domains,
+		IPv4Gateway:         n.IPv4Gateway,
+		IPv6Gateway:         n.IPv6Gateway,
+		Nameserver1:         n.Nameserver1,
+		Nameserver2:         n.Nameserver2,
+		Nameserver3:         n.Nameserver3,
+		HTTPProxy:           n.HTTPProxy,
+		Hosts:               n.Hosts,
+		ServiceAnnouncement: n.ServiceAnnouncement,
+	}
+}
+
+// Network provides typed access to the interface.*, staticroute.*, network.configuration.* and dns.* namespaces.
+type Network struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewNetwork creates a new Network service.
+func NewNetwork(client *Client) *Network {
+	return &Network{client: client}
+}
+
+// QueryInterfaces returns the network interfaces matching filters.
+func (n *Network) QueryInterfaces(ctx context.Context, filters []Filter, opts *QueryOptions) ([]NetworkInterface, error) {
+	var interfaces []NetworkInterface
+	err := n.client.callResult(ctx, "interface.query", queryParams(filters, opts), &interfaces)
+	return interfaces, err
+}
+
+// GetInterface returns a network interface by ID, e.g. "eno1".
+func (n *Network) GetInterface(ctx context.Context, id string) (*NetworkInterface, error) {
+	var iface NetworkInterface
+	if err := n.client.callResult(ctx, "interface.get_instance", []interface{}{id}, &iface); err != nil {
+		return nil, err
+	}
+	return &iface, nil
+}
+
+// CreateInterface creates a bridge, link aggregation or VLAN interface.
+// The change is pending until committed, see ApplyWithCheckin.
+func (n *Network) CreateInterface(ctx context.Context, req NetworkInterfaceRequest) (*NetworkInterface, error) {
+	var iface NetworkInterface
+	if err := n.client.callResult(ctx, "interface.create", []interface{}{req}, &iface); err != nil {
+		return nil, err
+	}
+	return &iface, nil
+}
+
+// UpdateInterface replaces the writable fields of a network interface.
+// The change is pending until committed, see ApplyWithCheckin.
+func (n *Network) UpdateInterface(ctx context.Context, id string, req NetworkInterfaceRequest) (*NetworkInterface, error) {
+	var iface NetworkInterface
+	if err := n.client.callResult(ctx, "interface.update", []interface{}{id, req}, &iface); err != nil {
+		return nil, err
+	}
+	return &iface, nil
+}
+
+// DeleteInterface deletes a network interface.
+// The change is pending until committed, see ApplyWithCheckin.
+func (n *Network) DeleteInterface(ctx context.Context, id string) error {
+	return n.client.callResult(ctx, "interface.delete", []interface{}{id}, nil)
+}
+
+// HasPendingChanges reports whether there are uncommitted interface changes.
+func (n *Network) HasPendingChanges(ctx context.Context) (bool, error) {
+	var pending bool
+	err := n.client.callResult(ctx, "interface.has_pending_changes", nil, &pending)
+	return pending, err
+}
+
+// Commit applies the pending interface changes. With rollback set the NAS
+// reverts them unless Checkin is called within checkinTimeout.
+func (n *Network) Commit(ctx context.Context, rollback bool, checkinTimeout time.Duration) error {
+	options := map[string]interface{}{
+		"rollback":        rollback,
+		"checkin_timeout": int(checkinTimeout.Seconds()),
+	}
+	return n.client.callResult(ctx, "interface.commit", []interface{}{options}, nil)
+}
+
+// Checkin confirms committed interface changes, cancelling the pending rollback.
+func (n *Network) Checkin(ctx context.Context) error {
+	return n.client.callResult(ctx, "interface.checkin", nil, nil)
+}
+
+// CheckinWaiting returns the time left to check in, or nil if no checkin is pending.
+func (n *Network) CheckinWaiting(ctx context.Context) (*time.Duration, error) {
+	var seconds *float64
+	if err := n.client.callResult(ctx, "interface.checkin_waiting", nil, &seconds); err != nil {
+		return nil, err
+	}
+	if seconds == nil {
+		return nil, nil
+	}
+	left := time.Duration(*seconds * float64(time.Second))
+	return &left, nil
+}
+
+// Rollback discards pending interface changes, or reverts committed changes awaiting checkin.
+func (n *Network) Rollback(ctx context.Context) error {
+	return n.client.callResult(ctx, "interface.rollback", nil, nil)
+}
+
+// WebsocketInterface returns the interface the client is connected through, or nil if unknown.
+func (n *Network) WebsocketInterface(ctx context.Context) (*NetworkInterface, error) {
+	var iface *NetworkInterface
+	err := n.client.callResult(ctx, "interface.websocket_interface", nil, &iface)
+	return iface, err
+}
+
+// QueryStaticRoutes returns the static routes matching filters.
+func (n *Network) QueryStaticRoutes(ctx context.Context, filters []Filter, opts *QueryOptions) ([]StaticRoute, error) {
+	var routes []StaticRoute
+	err := n.client.callResult(ctx, "staticroute.query", queryParams(filters, opts), &routes)
+	return routes, err
+}
+
+// CreateStaticRoute creates a static route.
+func (n *Network) CreateStaticRoute(ctx context.Context, req StaticRouteRequest) (*StaticRoute, error) {
+	var route StaticRoute
+	if err := n.client.callResult(ctx, "staticroute.create", []interface{}{req}, &route); err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// UpdateStaticRoute replaces the writable fields of a static route.
+func (n *Network) UpdateStaticRoute(ctx context.Context, id int, req StaticRouteRequest) (*StaticRoute, error) {
+	var route StaticRoute
+	if err := n.client.callResult(ctx, "staticroute.update", []interface{}{id, req}, &route); err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+// DeleteStaticRoute deletes a static route.
+func (n *Network) DeleteStaticRoute(ctx context.Context, id int) error {
+	return n.client.callResult(ctx, "staticroute.delete", []interface{}{id}, nil)
+}
+
+// Config returns the global network configuration.
+func (n *Network) Config(ctx context.Context) (*NetworkConfig, error) {
+	var config NetworkConfig
+	if err := n.client.callResult(ctx, "network.configuration.config", nil, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UpdateConfig replaces the global network configuration.
+func (n *Network) UpdateConfig(ctx context.Context, req NetworkConfigRequest) (*NetworkConfig, error) {
+	var config NetworkConfig
+	if err := n.client.callResult(ctx, "network.configuration.update", []interface{}{req}, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// Nameservers returns the nameservers currently in use, including those obtained via DHCP.
+func (n *Network) Nameservers(ctx context.Context) ([]string, error) {
+	var entries []struct {
+		Nameserver string `json:"nameserver"`
+	}
+	if err := n.client.callResult(ctx, "dns.query", nil, &entries); err != nil {
+		return nil, err
+	}
+	nameservers := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		nameservers = append(nameservers, entry.Nameserver)
+	}
+	return nameservers, nil
+}
+
+// SetNameservers configures up to three static nameservers, clearing the rest.
+func (n *Network) SetNameservers(ctx context.Context, nameservers ...string) (*NetworkConfig, error) {
+	if len(nameservers) > 3 {
+		return nil, fmt.Errorf("at most 3 nameservers can be configured, got %d", len(nameservers))
+	}
+	config, err := n.Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := config.Request()
+	servers := [3]string{}
+	copy(servers[:], nameservers)
+	req.Nameserver1, req.Nameserver2, req.Nameserver3 = servers[0], servers[1], servers[2]
+	return n.UpdateConfig(ctx, req)
+}
+
+// ApplyWithCheckin applies changes to the network interfaces and commits them
+// with a rollback after timeout. If the client is connected through an
+// address the changes remove, it reconnects on the new address of the same
+// interface. Once the NAS is reachable again the changes are checked in;
+// otherwise they are rolled back, explicitly if the NAS is still reachable
+// or by the NAS itself after timeout, and the client reconnects on its
+// original address.
+func (n *Network) ApplyWithCheckin(ctx context.Context, changes []InterfaceChange, timeout time.Duration) error {
+	if timeout < 10*time.Second {
+		return errors.New("checkin timeout must be at least 10 seconds")
+	}
+	originalURL := n.client.URL()
+
+	wsIface, err := n.WebsocketInterface(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := n.applyChanges(ctx, changes); err != nil {
+		if rbErr := n.Rollback(context.WithoutCancel(ctx)); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to discard pending changes: %w", rbErr))
+		}
+		return err
+	}
+
+	targetURL, err := n.urlAfterChanges(ctx, originalURL, wsIface)
+	if err != nil {
+		return errors.Join(err, n.Rollback(context.WithoutCancel(ctx)))
+	}
+
+	deadline := time.Now().Add(timeout)
+	if err := n.Commit(ctx, true, timeout); err != nil && !errors.Is(err, ErrClosed) {
+		return errors.Join(err, n.Rollback(context.WithoutCancel(ctx)))
+	}
+
+	// Check in on the target address, reconnecting if the connection went away
+	if err := n.checkin(ctx, targetURL, deadline); err != nil {
+		if n.ping(ctx) == nil {
+			return errors.Join(fmt.Errorf("checkin failed, changes rolled back: %w", err), n.Rollback(context.WithoutCancel(ctx)))
+		}
+		// Unreachable, wait for the NAS to roll back on its own
+		select {
+		case <-time.After(time.Until(deadline) + 5*time.Second):
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		}
+		if rcErr := n.reconnect(ctx, originalURL, time.Now().Add(timeout)); rcErr != nil {
+			return errors.Join(fmt.Errorf("checkin failed: %w", err), fmt.Errorf("failed to reconnect after rollback: %w", rcErr))
+		}
+		return fmt.Errorf("checkin failed, changes rolled back: %w", err)
+	}
+	return nil
+}
+
+// applyChanges applies the pending interface changes in order.
+func (n *Network) applyChanges(ctx context.Context, changes []InterfaceChange) error {
+	for _, change := range changes {
+		var err error
+		switch {
+		case change.ID == "" && change.Request != nil:
+			_, err = n.CreateInterface(ctx, *change.Request)
+		case change.ID == "":
+			err = errors.New("interface change without ID or request")
+		case change.Request == nil:
+			err = n.DeleteInterface(ctx, change.ID)
+		default:
+			_, err = n.UpdateInterface(ctx, change.ID, *change.Request)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// urlAfterChanges returns the URL the NAS is reachable on once the pending
+// changes are committed. Only URLs with an IP address as host are rewritten.
+func (n *Network) urlAfterChanges(ctx context.Context, currentURL string, wsIface *NetworkInterface) (string, error) {
+	u, err := url.Parse(currentURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	ip := net.ParseIP(u.Hostname())
+	if ip == nil || ip.IsLoopback() || wsIface == nil {
+		return currentURL, nil // Hostnames are expected to resolve to the new address
+	}
+
+	interfaces, err := n.QueryInterfaces(ctx, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	for _, iface := range interfaces {
+		for _, alias := range iface.Aliases {
+			if ip.Equal(net.ParseIP(alias.Address)) {
+				return currentURL, nil // The address is kept
+			}
+		}
+	}
+
+	family := "INET"
+	if ip.To4() == nil {
+		family = "INET6"
+	}
+	for _, iface := range interfaces {
+		if iface.ID != wsIface.ID {
+			continue
+		}
+		for _, alias := range iface.Aliases {
+			if alias.Type == family {
+				host := alias.Address
+				if port := u.Port(); port != "" {
+					host = net.JoinHostPort(host, port)
+				} else if family == "INET6" {
+					host = "[" + host + "]"
+				}
+				u.Host = host
+				return u.String(), nil
+			}
+		}
+		if iface.IPv4DHCP || iface.IPv6Auto {
+			return "", fmt.Errorf("the changes move %s to an address obtained automatically, connect via hostname instead", u.Hostname())
+		}
+	}
+	return "", fmt.Errorf("the changes remove the address %s the client is connected to", u.Hostname())
+}
+
+// checkin checks in the committed changes on targetURL, reconnecting until deadline if needed.
+func (n *Network) checkin(ctx context.Context, targetURL string, deadline time.Time) error {
+	if n.client.URL() != targetURL || n.ping(ctx) != nil {
+		if err := n.reconnect(ctx, targetURL, deadline); err != nil {
+			return err
+		}
+	}
+	checkinCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+	return n.Checkin(checkinCtx)
+}
+
+// reconnect reconnects the client to serverURL, retrying until deadline.
+func (n *Network) reconnect(ctx context.Context, serverURL string, deadline time.Time) error {
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err := n.client.ReconnectTo(attemptCtx, serverURL)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(2 * time.Second).After(deadline) {
+			return err
+		}
+		select {
+		case <-time.After(2 * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ping checks that the current connection still answers calls.
+func (n *Network) ping(ctx context.Context) error {
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return n.client.callResult(pingCtx, "core.ping", nil, nil)
+}
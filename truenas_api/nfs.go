@@ -0,0 +1,251 @@
+package truenas_api
+
+import (
+	"context"
+	"slices"
+)
+
+// NFSShare is an NFS export as returned by sharing.nfs.query.
+type NFSShare struct {
+	ID              int      `json:"id"`
+	Path            string   `json:"path"`
+	Aliases         []string `json:"aliases"`
+	Comment         string   `json:"comment"`
+	Networks        []string `json:"networks"` // Authorized networks in CIDR notation, empty allows all
+	Hosts           []string `json:"hosts"`    // Authorized hosts, empty allows all
+	ReadOnly        bool     `json:"ro"`
+	MaprootUser     string   `json:"maproot_user"`
+	MaprootGroup    string   `json:"maproot_group"`
+	MapallUser      string   `json:"mapall_user"`
+	MapallGroup     string   `json:"mapall_group"`
+	Security        []string `json:"security"` // "SYS", "KRB5", "KRB5I" or "KRB5P"
+	Enabled         bool     `json:"enabled"`
+	ExposeSnapshots bool     `json:"expose_snapshots"`
+	Locked          bool     `json:"locked"` // Indicates if the underlying dataset is locked
+}
+
+// NFSShareRequest holds the writable fields of an NFS export, used by create and update.
+type NFSShareRequest struct {
+	Path            string   `json:"path"`
+	Aliases         []string `json:"aliases"`
+	Comment         string   `json:"comment"`
+	Networks        []string `json:"networks"`
+	Hosts           []string `json:"hosts"`
+	ReadOnly        bool     `json:"ro"`
+	MaprootUser     string   `json:"maproot_user"`
+	MaprootGroup    string   `json:"maproot_group"`
+	MapallUser      string   `json:"mapall_user"`
+	MapallGroup     string   `json:"mapall_group"`
+	Security        []string `json:"security"`
+	Enabled         bool     `json:"enabled"`
+	ExposeSnapshots bool     `json:"expose_snapshots"`
+}
+
+// NewNFSShareRequest returns a request for an export with the middleware defaults.
+func NewNFSShareRequest(path string) NFSShareRequest {
+	return NFSShareRequest{
+		Path:     path,
+		Aliases:  []string{},
+		Networks: []string{},
+		Hosts:    []string{},
+		Security: []string{},
+		Enabled:  true,
+	}
+}
+
+// Request returns the writable fields of the export, to be modified and passed to Update.
+func (s NFSShare) Request() NFSShareRequest {
+	return NFSShareRequest{
+		Path:            s.Path,
+		Aliases:         s.Aliases,
+		Comment:         s.Comment,
+		Networks:        s.Networks,
+		Hosts:           s.Hosts,
+		ReadOnly:        s.ReadOnly,
+		MaprootUser:     s.MaprootUser,
+		MaprootGroup:    s.MaprootGroup,
+		MapallUser:      s.MapallUser,
+		MapallGroup:     s.MapallGroup,
+		Security:        s.Security,
+		Enabled:         s.Enabled,
+		ExposeSnapshots: s.ExposeSnapshots,
+	}
+}
+
+// NFSConfig is the NFS service configuration as returned by nfs.config.
+type NFSConfig struct {
+	ID              int      `json:"id"`
+	Servers         int      `json:"servers"` // Number of nfsd threads, 0 lets the server decide
+	AllowNonroot    bool     `json:"allow_nonroot"`
+	Protocols       []string `json:"protocols"` // "NFSV3" and/or "NFSV4"
+	V4Krb           bool     `json:"v4_krb"`
+	V4Domain        string   `json:"v4_domain"`
+	BindIP          []string `json:"bindip"`
+	MountdPort      int      `json:"mountd_port"`
+	RPCStatdPort    int      `json:"rpcstatd_port"`
+	RPCLockdPort    int      `json:"rpclockd_port"`
+	MountdLog       bool     `json:"mountd_log"`
+	StatdLockdLog   bool     `json:"statd_lockd_log"`
+	V4KrbEnabled    bool     `json:"v4_krb_enabled"`
+	UserdManageGIDs bool     `json:"userd_manage_gids"`
+	KeytabHasNFSSPN bool     `json:"keytab_has_nfs_spn"`
+	ManagedNFSD     bool     `json:"managed_nfsd"`
+	RDMA            bool     `json:"rdma"`
+}
+
+// NFSConfigRequest holds the writable fields of the NFS service configuration.
+type NFSConfigRequest struct {
+	Servers         int      `json:"servers,omitempty"`
+	AllowNonroot    bool     `json:"allow_nonroot"`
+	Protocols       []string `json:"protocols"`
+	V4Krb           bool     `json:"v4_krb"`
+	V4Domain        string   `json:"v4_domain"`
+	BindIP          []string `json:"bindip"`
+	MountdPort      int      `json:"mountd_port,omitempty"`
+	RPCStatdPort    int      `json:"rpcstatd_port,omitempty"`
+	RPCLockdPort    int      `json:"rpclockd_port,omitempty"`
+	MountdLog       bool     `json:"mountd_log"`
+	StatdLockdLog   bool     `json:"statd_lockd_log"`
+	UserdManageGIDs bool     `json:"userd_manage_gids"`
+	RDMA            bool     `json:"rdma"`
+}
+
+// Request returns the writable fields of the configuration, to be modified and passed to UpdateConfig.
+func (c NFSConfig) Request() NFSConfigRequest {
+	req := NFSConfigRequest{
+		AllowNonroot:    c.AllowNonroot,
+		Protocols:       c.Protocols,
+		V4Krb:           c.V4Krb,
+		V4Domain:        c.V4Domain,
+		BindIP:          c.BindIP,
+		MountdPort:      c.MountdPort,
+		RPCStatdPort:    c.RPCStatdPort,
+		RPCLockdPort:    c.RPCLockdPort,
+		MountdLog:       c.MountdLog,
+		StatdLockdLog:   c.StatdLockdLog,
+		UserdManageGIDs: c.UserdManageGIDs,
+		RDMA:            c.RDMA,
+	}
+	if !c.ManagedNFSD {
+		req.Servers = c.Servers // Only pin the thread count if it was pinned before
+	}
+	return req
+}
+
+// NFSShares provides typed access to the sharing.nfs.* and nfs.config namespaces.
+type NFSShares struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewNFSShares creates a new NFSShares service.
+func NewNFSShares(client *Client) *NFSShares {
+	return &NFSShares{client: client}
+}
+
+// Query returns the NFS exports matching filters.
+func (s *NFSShares) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]NFSShare, error) {
+	var shares []NFSShare
+	err := s.client.callResult(ctx, "sharing.nfs.query", queryParams(filters, opts), &shares)
+	return shares, err
+}
+
+// Get returns a single NFS export by ID.
+func (s *NFSShares) Get(ctx context.Context, id int) (*NFSShare, error) {
+	var share NFSShare
+	if err := s.client.callResult(ctx, "sharing.nfs.get_instance", []interface{}{id}, &share); err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// Create creates an NFS export.
+func (s *NFSShares) Create(ctx context.Context, req NFSShareRequest) (*NFSShare, error) {
+	var share NFSShare
+	if err := s.client.callResult(ctx, "sharing.nfs.create", []interface{}{req}, &share); err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// Update replaces the writable fields of an NFS export.
+func (s *NFSShares) Update(ctx context.Context, id int, req NFSShareRequest) (*NFSShare, error) {
+	var share NFSShare
+	if err := s.client.callResult(ctx, "sharing.nfs.update", []interface{}{id, req}, &share); err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// Delete deletes an NFS export.
+func (s *NFSShares) Delete(ctx context.Context, id int) error {
+	return s.client.callResult(ctx, "sharing.nfs.delete", []interface{}{id}, nil)
+}
+
+// modify applies change to the writable fields of an export and updates it.
+func (s *NFSShares) modify(ctx context.Context, id int, change func(req *NFSShareRequest)) (*NFSShare, error) {
+	share, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	req := share.Request()
+	change(&req)
+	return s.Update(ctx, id, req)
+}
+
+// AllowNetworks adds networks, in CIDR notation, to the authorized networks of an export.
+func (s *NFSShares) AllowNetworks(ctx context.Context, id int, networks ...string) (*NFSShare, error) {
+	return s.modify(ctx, id, func(req *NFSShareRequest) {
+		req.Networks = appendMissing(req.Networks, networks...)
+	})
+}
+
+// AllowHosts adds hosts to the authorized hosts of an export.
+func (s *NFSShares) AllowHosts(ctx context.Context, id int, hosts ...string) (*NFSShare, error) {
+	return s.modify(ctx, id, func(req *NFSShareRequest) {
+		req.Hosts = appendMissing(req.Hosts, hosts...)
+	})
+}
+
+// SetMaproot maps the root user of clients to user and group, clearing any mapall setting.
+func (s *NFSShares) SetMaproot(ctx context.Context, id int, user, group string) (*NFSShare, error) {
+	return s.modify(ctx, id, func(req *NFSShareRequest) {
+		req.MaprootUser, req.MaprootGroup = user, group
+		req.MapallUser, req.MapallGroup = "", "" // maproot and mapall are mutually exclusive
+	})
+}
+
+// SetMapall maps all users of clients to user and group, clearing any maproot setting.
+func (s *NFSShares) SetMapall(ctx context.Context, id int, user, group string) (*NFSShare, error) {
+	return s.modify(ctx, id, func(req *NFSShareRequest) {
+		req.MapallUser, req.MapallGroup = user, group
+		req.MaprootUser, req.MaprootGroup = "", ""
+	})
+}
+
+// Config returns the NFS service configuration.
+func (s *NFSShares) Config(ctx context.Context) (*NFSConfig, error) {
+	var config NFSConfig
+	if err := s.client.callResult(ctx, "nfs.config", nil, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UpdateConfig replaces the NFS service configuration.
+func (s *NFSShares) UpdateConfig(ctx context.Context, req NFSConfigRequest) (*NFSConfig, error) {
+	var config NFSConfig
+	if err := s.client.callResult(ctx, "nfs.update", []interface{}{req}, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// appendMissing appends the values not yet present in list.
+func appendMissing(list []string, values ...string) []string {
+	for _, value := range values {
+		if !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
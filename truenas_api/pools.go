@@ -0,0 +1,72 @@
+package truenas_api
+
+import (
+	"context"
+	"strconv"
+)
+
+// Pool is a storage pool as returned by pool.query.
+type Pool struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	GUID      string `json:"guid"`
+	Path      string `json:"path"`
+	Status    string `json:"status"` // "ONLINE", "DEGRADED", "FAULTED", ...
+	Healthy   bool   `json:"healthy"`
+	Warning   bool   `json:"warning"`
+	Size      int64  `json:"size"`      // Bytes
+	Allocated int64  `json:"allocated"` // Bytes
+	Free      int64  `json:"free"`      // Bytes
+}
+
+// Dataset is a ZFS dataset or zvol as returned by pool.dataset.query.
+type Dataset struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Pool       string          `json:"pool"`
+	Type       string          `json:"type"` // "FILESYSTEM" or "VOLUME"
+	Encrypted  bool            `json:"encrypted"`
+	Mountpoint string          `json:"mountpoint"`
+	Used       DatasetProperty `json:"used"`
+	Available  DatasetProperty `json:"available"`
+	Children   []Dataset       `json:"children"`
+}
+
+// DatasetProperty is a ZFS property of a dataset.
+type DatasetProperty struct {
+	Value    string      `json:"value"`    // Human readable, e.g. "1.5G"
+	Rawvalue string      `json:"rawvalue"` // e.g. "1610612736"
+	Parsed   interface{} `json:"parsed"`
+	Source   string      `json:"source"` // "LOCAL", "INHERITED", "DEFAULT", ...
+}
+
+// Bytes returns the raw value of a size property such as used or available.
+func (p DatasetProperty) Bytes() (int64, bool) {
+	n, err := strconv.ParseInt(p.Rawvalue, 10, 64)
+	return n, err == nil
+}
+
+// Pools provides typed access to the pool.* and pool.dataset.* namespaces.
+type Pools struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewPools creates a new Pools service.
+func NewPools(client *Client) *Pools {
+	return &Pools{client: client}
+}
+
+// Query returns the pools matching filters.
+func (p *Pools) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]Pool, error) {
+	var pools []Pool
+	err := p.client.callResult(ctx, "pool.query", queryParams(filters, opts), &pools)
+	return pools, err
+}
+
+// QueryDatasets returns the datasets matching filters. Pass extra options such
+// as "flat", "retrieve_children" or "properties" in opts.Extra to limit the result.
+func (p *Pools) QueryDatasets(ctx context.Context, filters []Filter, opts *QueryOptions) ([]Dataset, error) {
+	var datasets []Dataset
+	err := p.client.callResult(ctx, "pool.dataset.query", queryParams(filters, opts), &datasets)
+	return datasets, err
+}
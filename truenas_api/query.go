@@ -0,0 +1,104 @@
+package truenas_api
+
+import "context"
+
+// Filter is a single query filter, e.g. Filter{"name", "=", "nginx"}.
+// Filters can be combined with Filter{"OR", []Filter{...}}.
+type Filter []interface{}
+
+// QueryOptions are the options accepted by the *.query methods.
+type QueryOptions struct {
+	Select  []string               `json:"select,omitempty"`   // Fields to return
+	OrderBy []string               `json:"order_by,omitempty"` // Fields to sort by, prefix with "-" for descending
+	Limit   int                    `json:"limit,omitempty"`    // Maximum number of entries to return
+	Offset  int                    `json:"offset,omitempty"`   // Number of entries to skip
+	Extra   map[string]interface{} `json:"extra,omitempty"`    // Method specific options
+}
+
+// queryParams builds the [filters, options] parameters of a query call.
+func queryParams(filters []Filter, opts *QueryOptions) []interface{} {
+	if filters == nil {
+		filters = []Filter{} // The middleware expects a list, not null
+	}
+	if opts == nil {
+		opts = &QueryOptions{}
+	}
+	return []interface{}{filters, opts}
+}
+
+// Iterator pages through the results of a query method, fetching a page of
+// entries at a time:
+//
+//	for it.Next() {
+//		entry := it.Value()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type Iterator[T any] struct {
+	ctx      context.Context
+	client   *Client
+	method   string
+	params   func(opts *QueryOptions) []interface{} // Builds the call parameters for a page
+	opts     QueryOptions
+	pageSize int
+	offset   int // Offset of the next page
+	left     int // Entries left before reaching opts.Limit, -1 if unlimited
+	page     []T
+	value    T
+	err      error
+	done     bool
+}
+
+// newIterator returns an Iterator over method that fetches pageSize entries
+// per call, with the parameters built by params from the page's options.
+func newIterator[T any](ctx context.Context, client *Client, method string, params func(opts *QueryOptions) []interface{}, opts *QueryOptions, pageSize int) *Iterator[T] {
+	it := &Iterator[T]{ctx: ctx, client: client, method: method, params: params, pageSize: pageSize, left: -1}
+	if opts != nil {
+		it.opts = *opts
+	}
+	it.offset = it.opts.Offset
+	if it.opts.Limit > 0 {
+		it.left = it.opts.Limit
+	}
+	return it
+}
+
+// Next advances to the next entry, fetching the next page if needed. It
+// returns false when there are no more entries or an error occurred.
+func (it *Iterator[T]) Next() bool {
+	if len(it.page) == 0 && !it.done && it.err == nil {
+		it.fetch()
+	}
+	if len(it.page) == 0 || it.left == 0 {
+		return false
+	}
+	it.value, it.page = it.page[0], it.page[1:]
+	if it.left > 0 {
+		it.left--
+	}
+	return true
+}
+
+// fetch fetches the next page of entries.
+func (it *Iterator[T]) fetch() {
+	opts := it.opts
+	opts.Offset, opts.Limit = it.offset, it.pageSize
+	if it.left > 0 && it.left < it.pageSize {
+		opts.Limit = it.left
+	}
+	if err := it.client.callResult(it.ctx, it.method, it.params(&opts), &it.page); err != nil {
+		it.err = err
+		return
+	}
+	it.offset += len(it.page)
+	it.done = len(it.page) < opts.Limit
+}
+
+// Value returns the current entry.
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
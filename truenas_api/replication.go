@@ -0,0 +1,288 @@
+package truenas_api
+
+import (
+	"context"
+)
+
+// Replication is a replication task as returned by replication.query.
+type Replication struct {
+	ID                              int                     `json:"id"`
+	Name                            string                  `json:"name"`
+	Direction                       string                  `json:"direction"` // "PUSH" or "PULL"
+	Transport                       string                  `json:"transport"` // "SSH", "SSH+NETCAT" or "LOCAL"
+	SSHCredentials                  *ReplicationCredentials `json:"ssh_credentials"`
+	NetcatActiveSide                string                  `json:"netcat_active_side"` // "LOCAL" or "REMOTE"
+	NetcatActiveSideListenAddress   string                  `json:"netcat_active_side_listen_address"`
+	NetcatActiveSidePortMin         *int                    `json:"netcat_active_side_port_min"`
+	NetcatActiveSidePortMax         *int                    `json:"netcat_active_side_port_max"`
+	NetcatPassiveSideConnectAddress string                  `json:"netcat_passive_side_connect_address"`
+	Sudo                            bool                    `json:"sudo"`
+	SourceDatasets                  []string                `json:"source_datasets"`
+	TargetDataset                   string                  `json:"target_dataset"`
+	Recursive                       bool                    `json:"recursive"`
+	Exclude                         []string                `json:"exclude"`
+	Properties                      bool                    `json:"properties"`
+	PropertiesExclude               []string                `json:"properties_exclude"`
+	PropertiesOverride              map[string]string       `json:"properties_override"`
+	Replicate                       bool                    `json:"replicate"`
+	Encryption                      bool                    `json:"encryption"`
+	EncryptionInherit               bool                    `json:"encryption_inherit"`
+	EncryptionKey                   string                  `json:"encryption_key"`
+	EncryptionKeyFormat             string                  `json:"encryption_key_format"` // "HEX" or "PASSPHRASE"
+	EncryptionKeyLocation           string                  `json:"encryption_key_location"`
+	PeriodicSnapshotTasks           []SnapshotTask          `json:"periodic_snapshot_tasks"`
+	NamingSchema                    []string                `json:"naming_schema"`
+	AlsoIncludeNamingSchema         []string                `json:"also_include_naming_schema"`
+	NameRegex                       string                  `json:"name_regex"`
+	Auto                            bool                    `json:"auto"`
+	Schedule                        *Schedule               `json:"schedule"`
+	RestrictSchedule                *Schedule               `json:"restrict_schedule"`
+	OnlyMatchingSchedule            bool                    `json:"only_matching_schedule"`
+	AllowFromScratch                bool                    `json:"allow_from_scratch"`
+	Readonly                        string                  `json:"readonly"` // "SET", "REQUIRE" or "IGNORE"
+	HoldPendingSnapshots            bool                    `json:"hold_pending_snapshots"`
+	RetentionPolicy                 string                  `json:"retention_policy"` // "SOURCE", "CUSTOM" or "NONE"
+	LifetimeValue                   *int                    `json:"lifetime_value"`
+	LifetimeUnit                    string                  `json:"lifetime_unit"`
+	Lifetimes                       []ReplicationLifetime   `json:"lifetimes"`
+	Compression                     string                  `json:"compression"` // "LZ4", "PIGZ", "PLZIP" or empty
+	SpeedLimit                      *int                    `json:"speed_limit"` // Bytes per second
+	LargeBlock                      bool                    `json:"large_block"`
+	Embed                           bool                    `json:"embed"`
+	Compressed                      bool                    `json:"compressed"`
+	Retries                         int                     `json:"retries"`
+	LoggingLevel                    string                  `json:"logging_level"`
+	Enabled                         bool                    `json:"enabled"`
+	State                           ReplicationState        `json:"state"`
+	Job                             map[string]interface{}  `json:"job"` // Running or last job of the task, if any
+	HasEncryptedDatasetKeys         bool                    `json:"has_encrypted_dataset_keys"`
+}
+
+// ReplicationCredentials identifies the SSH connection a replication task uses.
+type ReplicationCredentials struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ReplicationLifetime is a custom retention rule for snapshots matching a schedule.
+type ReplicationLifetime struct {
+	Schedule      Schedule `json:"schedule"`
+	LifetimeValue int      `json:"lifetime_value"`
+	LifetimeUnit  string   `json:"lifetime_unit"`
+}
+
+// ReplicationState is the state of the last run of a replication task.
+type ReplicationState struct {
+	State        string    `json:"state"` // "PENDING", "RUNNING", "SUCCESS", "ERROR", "HOLD", ...
+	Datetime     *DateTime `json:"datetime"`
+	LastSnapshot string    `json:"last_snapshot"`
+	Error        string    `json:"error"`
+}
+
+// ReplicationRequest holds the writable fields of a replication task.
+type ReplicationRequest struct {
+	Name                            string                `json:"name"`
+	Direction                       string                `json:"direction"`
+	Transport                       string                `json:"transport"`
+	SSHCredentials                  *int                  `json:"ssh_credentials"` // Keychain credential ID, nil for LOCAL
+	NetcatActiveSide                string                `json:"netcat_active_side,omitempty"`
+	NetcatActiveSideListenAddress   string                `json:"netcat_active_side_listen_address,omitempty"`
+	NetcatActiveSidePortMin         *int                  `json:"netcat_active_side_port_min"`
+	NetcatActiveSidePortMax         *int                  `json:"netcat_active_side_port_max"`
+	NetcatPassiveSideConnectAddress string                `json:"netcat_passive_side_connect_address,omitempty"`
+	Sudo                            bool                  `json:"sudo"`
+	SourceDatasets                  []string              `json:"source_datasets"`
+	TargetDataset                   string                `json:"target_dataset"`
+	Recursive                       bool                  `json:"recursive"`
+	Exclude                         []string              `json:"exclude"`
+	Properties                      bool                  `json:"properties"`
+	PropertiesExclude               []string              `json:"properties_exclude"`
+	PropertiesOverride              map[string]string     `json:"properties_override"`
+	Replicate                       bool                  `json:"replicate"`
+	Encryption                      bool                  `json:"encryption"`
+	EncryptionInherit               bool                  `json:"encryption_inherit"`
+	EncryptionKey                   string                `json:"encryption_key,omitempty"`
+	EncryptionKeyFormat             string                `json:"encryption_key_format,omitempty"`
+	EncryptionKeyLocation           string                `json:"encryption_key_location,omitempty"`
+	PeriodicSnapshotTasks           []int                 `json:"periodic_snapshot_tasks"`
+	NamingSchema                    []string              `json:"naming_schema"`
+	AlsoIncludeNamingSchema         []string              `json:"also_include_naming_schema"`
+	NameRegex                       string                `json:"name_regex,omitempty"`
+	Auto                            bool                  `json:"auto"`
+	Schedule                        *Schedule             `json:"schedule"`
+	RestrictSchedule                *Schedule             `json:"restrict_schedule"`
+	OnlyMatchingSchedule            bool                  `json:"only_matching_schedule"`
+	AllowFromScratch                bool                  `json:"allow_from_scratch"`
+	Readonly                        string                `json:"readonly"`
+	HoldPendingSnapshots            bool                  `json:"hold_pending_snapshots"`
+	RetentionPolicy                 string                `json:"retention_policy"`
+	LifetimeValue                   *int                  `json:"lifetime_value"`
+	LifetimeUnit                    string                `json:"lifetime_unit,omitempty"`
+	Lifetimes                       []ReplicationLifetime `json:"lifetimes"`
+	Compression                     string                `json:"compression,omitempty"`
+	SpeedLimit                      *int                  `json:"speed_limit"`
+	LargeBlock                      bool                  `json:"large_block"`
+	Embed                           bool                  `json:"embed"`
+	Compressed                      bool                  `json:"compressed"`
+	Retries                         int                   `json:"retries"`
+	LoggingLevel                    string                `json:"logging_level,omitempty"`
+	Enabled                         bool                  `json:"enabled"`
+}
+
+// Request returns the writable fields of the task, to be modified and passed to Update.
+func (r Replication) Request() ReplicationRequest {
+	req := ReplicationRequest{
+		Name:                            r.Name,
+		Direction:                       r.Direction,
+		Transport:                       r.Transport,
+		NetcatActiveSide:                r.NetcatActiveSide,
+		NetcatActiveSideListenAddress:   r.NetcatActiveSideListenAddress,
+		NetcatActiveSidePortMin:         r.NetcatActiveSidePortMin,
+		NetcatActiveSidePortMax:         r.NetcatActiveSidePortMax,
+		NetcatPassiveSideConnectAddress: r.NetcatPassiveSideConnectAddress,
+		Sudo:                            r.Sudo,
+		SourceDatasets:                  r.SourceDatasets,
+		TargetDataset:                   r.TargetDataset,
+		Recursive:                       r.Recursive,
+		Exclude:                         r.Exclude,
+		Properties:                      r.Properties,
+		PropertiesExclude:               r.PropertiesExclude,
+		PropertiesOverride:              r.PropertiesOverride,
+		Replicate:                       r.Replicate,
+		Encryption:                      r.Encryption,
+		EncryptionInherit:               r.EncryptionInherit,
+		EncryptionKey:                   r.EncryptionKey,
+		EncryptionKeyFormat:             r.EncryptionKeyFormat,
+		EncryptionKeyLocation:           r.EncryptionKeyLocation,
+		PeriodicSnapshotTasks:           []int{},
+		NamingSchema:                    r.NamingSchema,
+		AlsoIncludeNamingSchema:         r.AlsoIncludeNamingSchema,
+		NameRegex:                       r.NameRegex,
+		Auto:                            r.Auto,
+		Schedule:                        r.Schedule,
+		RestrictSchedule:                r.RestrictSchedule,
+		OnlyMatchingSchedule:            r.OnlyMatchingSchedule,
+		AllowFromScratch:                r.AllowFromScratch,
+		Readonly:                        r.Readonly,
+		HoldPendingSnapshots:            r.HoldPendingSnapshots,
+		RetentionPolicy:                 r.RetentionPolicy,
+		LifetimeValue:                   r.LifetimeValue,
+		LifetimeUnit:                    r.LifetimeUnit,
+		Lifetimes:                       r.Lifetimes,
+		Compression:                     r.Compression,
+		SpeedLimit:                      r.SpeedLimit,
+		LargeBlock:                      r.LargeBlock,
+		Embed:                           r.Embed,
+		Compressed:                      r.Compressed,
+		Retries:                         r.Retries,
+		LoggingLevel:                    r.LoggingLevel,
+		Enabled:                         r.Enabled,
+	}
+	if r.SSHCredentials != nil {
+		req.SSHCredentials = &r.SSHCredentials.ID
+	}
+	for _, task := range r.PeriodicSnapshotTasks {
+		req.PeriodicSnapshotTasks = append(req.PeriodicSnapshotTasks, task.ID)
+	}
+	return req
+}
+
+// ReplicationSnapshotCount is the result of replication.count_eligible_manual_snapshots.
+type ReplicationSnapshotCount struct {
+	Total    int `json:"total"`
+	Eligible int `json:"eligible"` // Snapshots matching the naming schema or regex
+}
+
+// Replications provides typed access to the replication.* namespace.
+type Replications struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewReplications creates a new Replications service.
+func NewReplications(client *Client) *Replications {
+	return &Replications{client: client}
+}
+
+// Query returns the replication tasks matching filters.
+func (r *Replications) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]Replication, error) {
+	var tasks []Replication
+	err := r.client.callResult(ctx, "replication.query", queryParams(filters, opts), &tasks)
+	return tasks, err
+}
+
+// Get returns a single replication task by ID.
+func (r *Replications) Get(ctx context.Context, id int) (*Replication, error) {
+	var task Replication
+	if err := r.client.callResult(ctx, "replication.get_instance", []interface{}{id}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Create creates a replication task.
+func (r *Replications) Create(ctx context.Context, req ReplicationRequest) (*Replication, error) {
+	var task Replication
+	if err := r.client.callResult(ctx, "replication.create", []interface{}{req}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Update replaces the writable fields of a replication task.
+func (r *Replications) Update(ctx context.Context, id int, req ReplicationRequest) (*Replication, error) {
+	var task Replication
+	if err := r.client.callResult(ctx, "replication.update", []interface{}{id, req}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Delete deletes a replication task.
+func (r *Replications) Delete(ctx context.Context, id int) error {
+	return r.client.callResult(ctx, "replication.delete", []interface{}{id}, nil)
+}
+
+// Run starts a replication task now and tracks its job.
+func (r *Replications) Run(ctx context.Context, id int, callback JobCallback) (*Job, error) {
+	return r.client.callJob(ctx, "replication.run", []interface{}{id}, callback)
+}
+
+// RunOnetime runs a replication described by req without saving it as a task.
+func (r *Replications) RunOnetime(ctx context.Context, req ReplicationRequest, callback JobCallback) (*Job, error) {
+	return r.client.callJob(ctx, "replication.run_onetime", []interface{}{req}, callback)
+}
+
+// ListDatasets returns the datasets on the other side of a transport.
+// sshCredentials is the keychain credential ID, or nil for LOCAL.
+func (r *Replications) ListDatasets(ctx context.Context, transport string, sshCredentials *int) ([]string, error) {
+	var datasets []string
+	err := r.client.callResult(ctx, "replication.list_datasets", []interface{}{transport, sshCredentials}, &datasets)
+	return datasets, err
+}
+
+// ListNamingSchemas returns the naming schemas of all periodic snapshot tasks.
+func (r *Replications) ListNamingSchemas(ctx context.Context) ([]string, error) {
+	var schemas []string
+	err := r.client.callResult(ctx, "replication.list_naming_schemas", nil, &schemas)
+	return schemas, err
+}
+
+// CountEligibleManualSnapshots counts the snapshots of datasets that match
+// namingSchema or nameRegex and could therefore be replicated.
+func (r *Replications) CountEligibleManualSnapshots(ctx context.Context, datasets, namingSchema []string, nameRegex, transport string, sshCredentials *int) (*ReplicationSnapshotCount, error) {
+	params := map[string]interface{}{
+		"datasets":        datasets,
+		"naming_schema":   namingSchema,
+		"transport":       transport,
+		"ssh_credentials": sshCredentials,
+	}
+	if nameRegex != "" {
+		params["name_regex"] = nameRegex
+	}
+	var count ReplicationSnapshotCount
+	if err := r.client.callResult(ctx, "replication.count_eligible_manual_snapshots", []interface{}{params}, &count); err != nil {
+		return nil, err
+	}
+	return &count, nil
+}
@@ -0,0 +1,241 @@
+package truenas_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// RealtimeStats is a snapshot of the reporting.realtime event source.
+type RealtimeStats struct {
+	CPU             map[string]RealtimeCPU       `json:"cpu"` // Per CPU ("cpu0", ...) and aggregated ("cpu") usage
+	Memory          RealtimeMemory               `json:"memory"`
+	Disks           RealtimeDisks                `json:"disks"`
+	Interfaces      map[string]RealtimeInterface `json:"interfaces"`
+	ZFS             RealtimeZFS                  `json:"zfs"`
+	FailedToConnect bool                         `json:"failed_to_connect"` // Indicates if the stats collector was unreachable
+}
+
+// RealtimeCPU is the usage of a CPU, in percent, since the previous snapshot.
+type RealtimeCPU struct {
+	User               float64  `json:"user"`
+	Nice               float64  `json:"nice"`
+	System             float64  `json:"system"`
+	Idle               float64  `json:"idle"`
+	IOWait             float64  `json:"iowait"`
+	IRQ                float64  `json:"irq"`
+	SoftIRQ            float64  `json:"softirq"`
+	Steal              float64  `json:"steal"`
+	Guest              float64  `json:"guest"`
+	GuestNice          float64  `json:"guest_nice"`
+	Usage              float64  `json:"usage"`
+	AggregatedUsage    float64  `json:"aggregated_usage"`
+	TemperatureCelsius *float64 `json:"temperature_celsius"` // nil if the CPU has no sensor
+}
+
+// RealtimeMemory is the memory usage in bytes.
+type RealtimeMemory struct {
+	PhysicalMemoryTotal     int64 `json:"physical_memory_total"`
+	PhysicalMemoryAvailable int64 `json:"physical_memory_available"`
+	ARCSize                 int64 `json:"arc_size"`
+	ARCFreeMemory           int64 `json:"arc_free_memory"`
+	ARCAvailableMemory      int64 `json:"arc_available_memory"`
+}
+
+// RealtimeDisks is the aggregated I/O of all disks since the previous snapshot.
+type RealtimeDisks struct {
+	Busy       float64 `json:"busy"`
+	ReadBytes  float64 `json:"read_bytes"`
+	WriteBytes float64 `json:"write_bytes"`
+	ReadOps    float64 `json:"read_ops"`
+	WriteOps   float64 `json:"write_ops"`
+}
+
+// RealtimeInterface is the traffic of a network interface.
+type RealtimeInterface struct {
+	LinkState         string  `json:"link_state"` // "LINK_STATE_UP", "LINK_STATE_DOWN", ...
+	Speed             *int    `json:"speed"`      // Link speed in Mb/s, nil if unknown
+	ReceivedBytes     float64 `json:"received_bytes"`
+	SentBytes         float64 `json:"sent_bytes"`
+	ReceivedBytesRate float64 `json:"received_bytes_rate"` // Bytes per second
+	SentBytesRate     float64 `json:"sent_bytes_rate"`     // Bytes per second
+}
+
+// RealtimeZFS is the ZFS ARC usage.
+type RealtimeZFS struct {
+	ARCMaxSize     int64   `json:"arc_max_size"`
+	ARCSize        int64   `json:"arc_size"`
+	CacheHitRatio  float64 `json:"cache_hit_ratio"`
+	DemandAccesses float64 `json:"demand_accesses_per_second"`
+	DemandHits     float64 `json:"demand_data_hits_per_second"`
+	DemandMisses   float64 `json:"demand_data_misses_per_second"`
+}
+
+// Reporting time units accepted by ReportingQuery.Unit.
+const (
+	ReportingUnitHour  = "HOUR"
+	ReportingUnitDay   = "DAY"
+	ReportingUnitWeek  = "WEEK"
+	ReportingUnitMonth = "MONTH"
+	ReportingUnitYear  = "YEAR"
+)
+
+// ReportingGraph identifies a graph and one of its identifiers, e.g. {"disk", "sda"}.
+type ReportingGraph struct {
+	Name       string `json:"name"`
+	Identifier string `json:"identifier,omitempty"` // Empty for graphs without identifiers
+}
+
+// ReportingQuery selects the time range of a reporting query: either a Unit
+// counted back from now (paged with Page), or an explicit Start and End.
+type ReportingQuery struct {
+	Unit      string    // One of the ReportingUnit* constants
+	Page      int       // Number of units to go back from now, 0 for the most recent
+	Start     time.Time // Start of an explicit range
+	End       time.Time // End of an explicit range
+	Aggregate bool      // Include min/max/mean aggregations
+}
+
+// params returns the middleware representation of the query.
+func (q ReportingQuery) params() map[string]interface{} {
+	params := map[string]interface{}{"aggregate": q.Aggregate}
+	if q.Unit != "" {
+		params["unit"] = q.Unit
+		params["page"] = q.Page
+	}
+	if !q.Start.IsZero() {
+		params["start"] = q.Start.Unix()
+	}
+	if !q.End.IsZero() {
+		params["end"] = q.End.Unix()
+	}
+	return params
+}
+
+// ReportingGraphInfo describes an available graph, as returned by reporting.graphs.
+type ReportingGraphInfo struct {
+	Name          string   `json:"name"`
+	Title         string   `json:"title"`
+	VerticalLabel string   `json:"vertical_label"`
+	Identifiers   []string `json:"identifiers"` // nil for graphs without identifiers
+}
+
+// ReportingData is the data of a graph, as returned by reporting.get_data.
+type ReportingData struct {
+	Name         string                 `json:"name"`
+	Identifier   string                 `json:"identifier"`
+	Data         [][]*float64           `json:"data"`   // Rows of [time, value...], nil for missing values
+	Legend       []string               `json:"legend"` // Column names, starting with "time"
+	Start        int64                  `json:"start"`
+	End          int64                  `json:"end"`
+	Aggregations map[string]interface{} `json:"aggregations"` // "min", "max" and "mean" per column
+}
+
+// ReportingSeries is a single column of ReportingData.
+type ReportingSeries struct {
+	Legend string
+	Points []ReportingPoint
+}
+
+// ReportingPoint is a single value of a series. Missing values are NaN.
+type ReportingPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series splits the data rows into one series per legend entry after "time".
+func (d ReportingData) Series() []ReportingSeries {
+	if len(d.Legend) < 2 {
+		return nil
+	}
+	series := make([]ReportingSeries, len(d.Legend)-1)
+	for i := range series {
+		series[i].Legend = d.Legend[i+1]
+	}
+	for _, row := range d.Data {
+		if len(row) == 0 || row[0] == nil {
+			continue // Skip rows without a timestamp
+		}
+		ts := time.Unix(int64(*row[0]), 0)
+		for i := range series {
+			value := math.NaN()
+			if i+1 < len(row) && row[i+1] != nil {
+				value = *row[i+1]
+			}
+			series[i].Points = append(series[i].Points, ReportingPoint{Time: ts, Value: value})
+		}
+	}
+	return series
+}
+
+// Reporting provides typed access to the reporting.* namespace.
+type Reporting struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewReporting creates a new Reporting service.
+func NewReporting(client *Client) *Reporting {
+	return &Reporting{client: client}
+}
+
+// Realtime delivers a RealtimeStats snapshot every interval, rounded up to
+// whole seconds, or at the server default rate if interval is 0, until ctx is
+// done or the connection closes.
+func (r *Reporting) Realtime(ctx context.Context, interval time.Duration) (<-chan RealtimeStats, error) {
+	name := "reporting.realtime"
+	if interval > 0 {
+		seconds := int((interval + time.Second - 1) / time.Second) // The server takes whole seconds, at least 1
+		args, err := json.Marshal(map[string]interface{}{"interval": seconds})
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode realtime arguments: %w", err)
+		}
+		name += ":" + string(args)
+	}
+
+	events, err := r.client.Subscribe(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make(chan RealtimeStats)
+	go func() {
+		defer close(snapshots)
+		for event := range events {
+			var stats RealtimeStats
+			if err := json.Unmarshal(event.Fields, &stats); err != nil {
+				continue // Ignore if the snapshot can't be parsed
+			}
+			select {
+			case snapshots <- stats:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return snapshots, nil
+}
+
+// Graphs returns the available graphs.
+func (r *Reporting) Graphs(ctx context.Context) ([]ReportingGraphInfo, error) {
+	var graphs []ReportingGraphInfo
+	err := r.client.callResult(ctx, "reporting.graphs", queryParams(nil, nil), &graphs)
+	return graphs, err
+}
+
+// GetData returns the historical data of graphs over the range of query.
+func (r *Reporting) GetData(ctx context.Context, graphs []ReportingGraph, query ReportingQuery) ([]ReportingData, error) {
+	return r.getData(ctx, "reporting.get_data", graphs, query)
+}
+
+// NetdataGetData is GetData using reporting.netdata_get_data, as required by releases that expose both.
+func (r *Reporting) NetdataGetData(ctx context.Context, graphs []ReportingGraph, query ReportingQuery) ([]ReportingData, error) {
+	return r.getData(ctx, "reporting.netdata_get_data", graphs, query)
+}
+
+// getData requests graph data from method.
+func (r *Reporting) getData(ctx context.Context, method string, graphs []ReportingGraph, query ReportingQuery) ([]ReportingData, error) {
+	var data []ReportingData
+	err := r.client.callResult(ctx, method, []interface{}{graphs, query.params()}, &data)
+	return data, err
+}
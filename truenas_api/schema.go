@@ -0,0 +1,147 @@
+package truenas_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// MethodSchema describes an API method as returned by core.get_methods.
+type MethodSchema struct {
+	Description  string    `json:"description"`
+	Accepts      []*Schema `json:"accepts"` // One schema per positional parameter
+	Returns      []*Schema `json:"returns"` // The result, if described
+	Job          bool      `json:"job"`     // The method returns a job ID
+	Downloadable bool      `json:"downloadable"`
+	Uploadable   bool      `json:"uploadable"`
+	Filterable   bool      `json:"filterable"`
+	Roles        []string  `json:"roles"`
+}
+
+// SchemaType holds the JSON schema "type", which may be a single type or a list.
+type SchemaType []string
+
+// UnmarshalJSON accepts both "string" and ["string", "null"].
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = SchemaType{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// Has reports whether t includes name, e.g. "null".
+func (t SchemaType) Has(name string) bool {
+	for _, v := range t {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Schema is the subset of JSON schema used by the middleware to describe
+// method parameters and results.
+type Schema struct {
+	Ref           string             `json:"$ref,omitempty"`  // e.g. "#/$defs/PoolEntry"
+	Defs          map[string]*Schema `json:"$defs,omitempty"` // Definitions referenced by Ref
+	Type          SchemaType         `json:"type,omitempty"`
+	Title         string             `json:"title,omitempty"`
+	Description   string             `json:"description,omitempty"`
+	Name          string             `json:"_name_,omitempty"`     // Parameter name, in older middleware versions
+	ParamRequired *bool              `json:"_required_,omitempty"` // Whether the parameter is required, in older middleware versions
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"-"` // nil if not restricted
+	Items                *Schema            `json:"-"`
+	PrefixItems          []*Schema          `json:"prefixItems,omitempty"` // Also set from a list of "items"
+
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	AllOf []*Schema `json:"allOf,omitempty"`
+
+	Enum      []interface{}   `json:"enum,omitempty"`
+	Const     json.RawMessage `json:"const,omitempty"`
+	Default   json.RawMessage `json:"default,omitempty"`
+	Format    string          `json:"format,omitempty"`
+	Pattern   string          `json:"pattern,omitempty"`
+	MinLength *int            `json:"minLength,omitempty"`
+	MaxLength *int            `json:"maxLength,omitempty"`
+	Minimum   *float64        `json:"minimum,omitempty"`
+	Maximum   *float64        `json:"maximum,omitempty"`
+	MinItems  *int            `json:"minItems,omitempty"`
+	MaxItems  *int            `json:"maxItems,omitempty"`
+
+	Never bool `json:"-"` // The schema is false, no value is valid
+}
+
+// schemaFields is Schema without its methods, to decode the regular fields.
+type schemaFields Schema
+
+// UnmarshalJSON decodes a schema, including the boolean schemas true and false
+// and "items" and "additionalProperties" in their different forms.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{Never: true}
+		return nil
+	}
+
+	var raw struct {
+		*schemaFields
+		Items                json.RawMessage `json:"items"`
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	raw.schemaFields = (*schemaFields)(s)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if items := bytes.TrimSpace(raw.Items); len(items) > 0 {
+		if items[0] == '[' {
+			if err := json.Unmarshal(items, &s.PrefixItems); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(items, &s.Items); err != nil {
+			return err
+		}
+	}
+	if additional := bytes.TrimSpace(raw.AdditionalProperties); len(additional) > 0 && string(additional) != "true" {
+		if err := json.Unmarshal(additional, &s.AdditionalProperties); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParamName returns the name of a method parameter described by s.
+func (s *Schema) ParamName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Title
+}
+
+// RequiredParam reports whether a method parameter described by s must be
+// passed: as marked by older middleware versions, or else if it has no default.
+func (s *Schema) RequiredParam() bool {
+	if s.ParamRequired != nil {
+		return *s.ParamRequired
+	}
+	return len(s.Default) == 0
+}
+
+// RefName returns the definition name of Ref, e.g. "PoolEntry" for "#/$defs/PoolEntry".
+func (s *Schema) RefName() string {
+	return s.Ref[strings.LastIndex(s.Ref, "/")+1:]
+}
@@ -0,0 +1,129 @@
+package truenas_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Service states reported by service.query.
+const (
+	ServiceRunning = "RUNNING"
+	ServiceStopped = "STOPPED"
+	ServiceCrashed = "CRASHED"
+)
+
+// Service is a system service as returned by service.query.
+type Service struct {
+	ID      int    `json:"id"`
+	Service string `json:"service"` // Service name, e.g. "cifs", "nfs", "ssh", "ups"
+	Enable  bool   `json:"enable"`  // Start on boot
+	State   string `json:"state"`   // One of the Service* states
+	Pids    []int  `json:"pids"`
+}
+
+// Running reports whether the service is running.
+func (s Service) Running() bool {
+	return s.State == ServiceRunning
+}
+
+// Services provides typed access to the service.* namespace.
+type Services struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewServices creates a new Services service.
+func NewServices(client *Client) *Services {
+	return &Services{client: client}
+}
+
+// Query returns the services matching filters.
+func (s *Services) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]Service, error) {
+	var services []Service
+	err := s.client.callResult(ctx, "service.query", queryParams(filters, opts), &services)
+	return services, err
+}
+
+// Get returns a service by name, e.g. "cifs" for SMB.
+func (s *Services) Get(ctx context.Context, name string) (*Service, error) {
+	services, err := s.Query(ctx, []Filter{{"service", "=", name}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("service %q not found", name)
+	}
+	return &services[0], nil
+}
+
+// Start starts a service. An error is returned if the service fails to start.
+func (s *Services) Start(ctx context.Context, name string) error {
+	return s.control(ctx, "service.start", name)
+}
+
+// Stop stops a service.
+func (s *Services) Stop(ctx context.Context, name string) error {
+	return s.control(ctx, "service.stop", name)
+}
+
+// Restart restarts a service.
+func (s *Services) Restart(ctx context.Context, name string) error {
+	return s.control(ctx, "service.restart", name)
+}
+
+// Reload reloads the configuration of a running service.
+func (s *Services) Reload(ctx context.Context, name string) error {
+	return s.control(ctx, "service.reload", name)
+}
+
+// control calls a service.start-like method. Depending on the TrueNAS version
+// these return a boolean or run as a job, which is waited for.
+func (s *Services) control(ctx context.Context, method, name string) error {
+	if err := s.client.SubscribeToJobs(); err != nil {
+		return err
+	}
+	var result json.RawMessage
+	options := map[string]interface{}{"silent": false}
+	if err := s.client.callResult(ctx, method, []interface{}{name, options}, &result); err != nil {
+		return err
+	}
+
+	var ok bool
+	if err := json.Unmarshal(result, &ok); err == nil {
+		if !ok {
+			return fmt.Errorf("%s: %s failed", method, name)
+		}
+		return nil
+	}
+	var jobID int64
+	if err := json.Unmarshal(result, &jobID); err != nil {
+		return fmt.Errorf("failed to parse %s result: %w", method, err)
+	}
+	return s.client.trackJob(ctx, jobID, method, nil).Wait(ctx)
+}
+
+// SetEnable sets whether a service starts on boot.
+func (s *Services) SetEnable(ctx context.Context, name string, enable bool) error {
+	return s.client.callResult(ctx, "service.update", []interface{}{name, map[string]interface{}{"enable": enable}}, nil)
+}
+
+// WaitForState polls a service every interval until it reaches state or ctx is done.
+func (s *Services) WaitForState(ctx context.Context, name, state string, interval time.Duration) (*Service, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		service, err := s.Get(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if service.State == state {
+			return service, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return service, fmt.Errorf("service %s is %s, not %s: %w", name, service.State, state, ctx.Err())
+		}
+	}
+}
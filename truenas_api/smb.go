@@ -0,0 +1,365 @@
+package truenas_api
+
+import (
+	"context"
+	"fmt"
+)
+
+// SMBShare is an SMB share as returned by sharing.smb.query.
+type SMBShare struct {
+	ID               int           `json:"id"`
+	Purpose          string        `json:"purpose"` // Preset name, see SMBShares.Presets
+	Path             string        `json:"path"`
+	PathSuffix       string        `json:"path_suffix"`
+	Home             bool          `json:"home"`
+	Name             string        `json:"name"`
+	Comment          string        `json:"comment"`
+	ReadOnly         bool          `json:"ro"`
+	Browsable        bool          `json:"browsable"`
+	Timemachine      bool          `json:"timemachine"`
+	TimemachineQuota int           `json:"timemachine_quota"`
+	RecycleBin       bool          `json:"recyclebin"`
+	GuestOK          bool          `json:"guestok"`
+	ABE              bool          `json:"abe"` // Access based share enumeration
+	HostsAllow       []string      `json:"hostsallow"`
+	HostsDeny        []string      `json:"hostsdeny"`
+	AuxSMBConf       string        `json:"auxsmbconf"`
+	AAPLNameMangling bool          `json:"aapl_name_mangling"`
+	ACL              bool          `json:"acl"`
+	DurableHandle    bool          `json:"durablehandle"`
+	ShadowCopy       bool          `json:"shadowcopy"`
+	Streams          bool          `json:"streams"`
+	FSRVP            bool          `json:"fsrvp"`
+	Enabled          bool          `json:"enabled"`
+	AFP              bool          `json:"afp"`
+	Audit            SMBShareAudit `json:"audit"`
+	Locked           bool          `json:"locked"`     // Indicates if the underlying dataset is locked
+	PathLocal        string        `json:"path_local"` // Local path for shares with a path suffix or cluster path
+}
+
+// SMBShareAudit configures auditing of an SMB share.
+type SMBShareAudit struct {
+	Enable     bool     `json:"enable"`
+	WatchList  []string `json:"watch_list"`  // Groups to audit, empty audits everyone
+	IgnoreList []string `json:"ignore_list"` // Groups to exclude from auditing
+}
+
+// SMBShareRequest holds the writable fields of an SMB share, used by create and update.
+type SMBShareRequest struct {
+	Purpose          string        `json:"purpose,omitempty"`
+	Path             string        `json:"path"`
+	PathSuffix       string        `json:"path_suffix"`
+	Home             bool          `json:"home"`
+	Name             string        `json:"name"`
+	Comment          string        `json:"comment"`
+	ReadOnly         bool          `json:"ro"`
+	Browsable        bool          `json:"browsable"`
+	Timemachine      bool          `json:"timemachine"`
+	TimemachineQuota int           `json:"timemachine_quota"`
+	RecycleBin       bool          `json:"recyclebin"`
+	GuestOK          bool          `json:"guestok"`
+	ABE              bool          `json:"abe"`
+	HostsAllow       []string      `json:"hostsallow"`
+	HostsDeny        []string      `json:"hostsdeny"`
+	AuxSMBConf       string        `json:"auxsmbconf"`
+	AAPLNameMangling bool          `json:"aapl_name_mangling"`
+	ACL              bool          `json:"acl"`
+	DurableHandle    bool          `json:"durablehandle"`
+	ShadowCopy       bool          `json:"shadowcopy"`
+	Streams          bool          `json:"streams"`
+	FSRVP            bool          `json:"fsrvp"`
+	Enabled          bool          `json:"enabled"`
+	AFP              bool          `json:"afp"`
+	Audit            SMBShareAudit `json:"audit"`
+}
+
+// NewSMBShareRequest returns a request for a share with the middleware defaults.
+func NewSMBShareRequest(name, path string) SMBShareRequest {
+	return SMBShareRequest{
+		Purpose:       "DEFAULT_SHARE",
+		Path:          path,
+		Name:          name,
+		Browsable:     true,
+		ACL:           true,
+		DurableHandle: true,
+		ShadowCopy:    true,
+		Streams:       true,
+		Enabled:       true,
+		HostsAllow:    []string{},
+		HostsDeny:     []string{},
+		Audit:         SMBShareAudit{WatchList: []string{}, IgnoreList: []string{}},
+	}
+}
+
+// Request returns the writable fields of the share, to be modified and passed to Update.
+func (s SMBShare) Request() SMBShareRequest {
+	return SMBShareRequest{
+		Purpose:          s.Purpose,
+		Path:             s.Path,
+		PathSuffix:       s.PathSuffix,
+		Home:             s.Home,
+		Name:             s.Name,
+		Comment:          s.Comment,
+		ReadOnly:         s.ReadOnly,
+		Browsable:        s.Browsable,
+		Timemachine:      s.Timemachine,
+		TimemachineQuota: s.TimemachineQuota,
+		RecycleBin:       s.RecycleBin,
+		GuestOK:          s.GuestOK,
+		ABE:              s.ABE,
+		HostsAllow:       s.HostsAllow,
+		HostsDeny:        s.HostsDeny,
+		AuxSMBConf:       s.AuxSMBConf,
+		AAPLNameMangling: s.AAPLNameMangling,
+		ACL:              s.ACL,
+		DurableHandle:    s.DurableHandle,
+		ShadowCopy:       s.ShadowCopy,
+		Streams:          s.Streams,
+		FSRVP:            s.FSRVP,
+		Enabled:          s.Enabled,
+		AFP:              s.AFP,
+		Audit:            s.Audit,
+	}
+}
+
+// SMBSharePreset is a share purpose preset as returned by sharing.smb.presets.
+type SMBSharePreset struct {
+	VerboseName string                 `json:"verbose_name"`
+	Params      map[string]interface{} `json:"params"` // Share fields the preset sets
+}
+
+// SMBShareACL is the share level ACL of an SMB share.
+type SMBShareACL struct {
+	ShareName string             `json:"share_name"`
+	ShareACL  []SMBShareACLEntry `json:"share_acl"`
+}
+
+// SMBShareACLEntry is a single share ACL entry. Either WhoSID or WhoID identifies the principal.
+type SMBShareACLEntry struct {
+	WhoSID string          `json:"ae_who_sid,omitempty"`
+	WhoID  *SMBShareACLWho `json:"ae_who_id,omitempty"`
+	WhoStr string          `json:"ae_who_str,omitempty"` // Set by the server, ignored on update
+	Perm   string          `json:"ae_perm"`              // "FULL", "CHANGE" or "READ"
+	Type   string          `json:"ae_type"`              // "ALLOWED" or "DENIED"
+}
+
+// SMBShareACLWho identifies a principal of a share ACL entry by Unix ID.
+type SMBShareACLWho struct {
+	IDType string `json:"id_type"` // "USER", "GROUP" or "BOTH"
+	ID     int    `json:"id"`
+}
+
+// samePrincipal reports whether two entries apply to the same principal. The
+// server fills in the SID of every entry it returns, so Unix IDs are compared
+// first and SIDs only if either entry has no ID.
+func (e SMBShareACLEntry) samePrincipal(other SMBShareACLEntry) bool {
+	if e.WhoID != nil && other.WhoID != nil {
+		return *e.WhoID == *other.WhoID
+	}
+	return e.WhoSID != "" && other.WhoSID != "" && e.WhoSID == other.WhoSID
+}
+
+// SMBConfig is the SMB service configuration as returned by smb.config.
+type SMBConfig struct {
+	ID              int      `json:"id"`
+	NetbiosName     string   `json:"netbiosname"`
+	NetbiosAlias    []string `json:"netbiosalias"`
+	Workgroup       string   `json:"workgroup"`
+	Description     string   `json:"description"`
+	EnableSMB1      bool     `json:"enable_smb1"`
+	UnixCharset     string   `json:"unixcharset"`
+	LocalMaster     bool     `json:"localmaster"`
+	Syslog          bool     `json:"syslog"`
+	AAPLExtensions  bool     `json:"aapl_extensions"`
+	AdminGroup      string   `json:"admin_group"`
+	Guest           string   `json:"guest"`
+	FileMask        string   `json:"filemask"`
+	DirMask         string   `json:"dirmask"`
+	NTLMv1Auth      bool     `json:"ntlmv1_auth"`
+	Multichannel    bool     `json:"multichannel"`
+	Encryption      string   `json:"encryption"` // "DEFAULT", "NEGOTIATE", "DESIRED" or "REQUIRED"
+	BindIP          []string `json:"bindip"`
+	LogLevel        string   `json:"loglevel"`
+	SearchProtocols []string `json:"search_protocols"`
+	CifsSID         string   `json:"cifs_SID"`
+	ServerSID       string   `json:"server_sid"`
+	NextRID         int      `json:"next_rid"`
+}
+
+// SMBConfigRequest holds the writable fields of the SMB service configuration.
+type SMBConfigRequest struct {
+	NetbiosName     string   `json:"netbiosname"`
+	NetbiosAlias    []string `json:"netbiosalias"`
+	Workgroup       string   `json:"workgroup"`
+	Description     string   `json:"description"`
+	EnableSMB1      bool     `json:"enable_smb1"`
+	UnixCharset     string   `json:"unixcharset"`
+	LocalMaster     bool     `json:"localmaster"`
+	Syslog          bool     `json:"syslog"`
+	AAPLExtensions  bool     `json:"aapl_extensions"`
+	AdminGroup      string   `json:"admin_group"`
+	Guest           string   `json:"guest"`
+	FileMask        string   `json:"filemask"`
+	DirMask         string   `json:"dirmask"`
+	NTLMv1Auth      bool     `json:"ntlmv1_auth"`
+	Multichannel    bool     `json:"multichannel"`
+	Encryption      string   `json:"encryption"`
+	BindIP          []string `json:"bindip"`
+	LogLevel        string   `json:"loglevel"`
+	SearchProtocols []string `json:"search_protocols"`
+}
+
+// Request returns the writable fields of the configuration, to be modified and passed to UpdateConfig.
+func (c SMBConfig) Request() SMBConfigRequest {
+	return SMBConfigRequest{
+		NetbiosName:     c.NetbiosName,
+		NetbiosAlias:    c.NetbiosAlias,
+		Workgroup:       c.Workgroup,
+		Description:     c.Description,
+		EnableSMB1:      c.EnableSMB1,
+		UnixCharset:     c.UnixCharset,
+		LocalMaster:     c.LocalMaster,
+		Syslog:          c.Syslog,
+		AAPLExtensions:  c.AAPLExtensions,
+		AdminGroup:      c.AdminGroup,
+		Guest:           c.Guest,
+		FileMask:        c.FileMask,
+		DirMask:         c.DirMask,
+		NTLMv1Auth:      c.NTLMv1Auth,
+		Multichannel:    c.Multichannel,
+		Encryption:      c.Encryption,
+		BindIP:          c.BindIP,
+		LogLevel:        c.LogLevel,
+		SearchProtocols: c.SearchProtocols,
+	}
+}
+
+// SMBShares provides typed access to the sharing.smb.* and smb.config namespaces.
+type SMBShares struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewSMBShares creates a new SMBShares service.
+func NewSMBShares(client *Client) *SMBShares {
+	return &SMBShares{client: client}
+}
+
+// Query returns the SMB shares matching filters.
+func (s *SMBShares) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]SMBShare, error) {
+	var shares []SMBShare
+	err := s.client.callResult(ctx, "sharing.smb.query", queryParams(filters, opts), &shares)
+	return shares, err
+}
+
+// Get returns a single SMB share by ID.
+func (s *SMBShares) Get(ctx context.Context, id int) (*SMBShare, error) {
+	var share SMBShare
+	if err := s.client.callResult(ctx, "sharing.smb.get_instance", []interface{}{id}, &share); err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// Create creates an SMB share.
+func (s *SMBShares) Create(ctx context.Context, req SMBShareRequest) (*SMBShare, error) {
+	var share SMBShare
+	if err := s.client.callResult(ctx, "sharing.smb.create", []interface{}{req}, &share); err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// Update replaces the writable fields of an SMB share.
+func (s *SMBShares) Update(ctx context.Context, id int, req SMBShareRequest) (*SMBShare, error) {
+	var share SMBShare
+	if err := s.client.callResult(ctx, "sharing.smb.update", []interface{}{id, req}, &share); err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// Delete deletes an SMB share.
+func (s *SMBShares) Delete(ctx context.Context, id int) error {
+	return s.client.callResult(ctx, "sharing.smb.delete", []interface{}{id}, nil)
+}
+
+// Presets returns the share purpose presets, keyed by purpose name.
+func (s *SMBShares) Presets(ctx context.Context) (map[string]SMBSharePreset, error) {
+	var presets map[string]SMBSharePreset
+	err := s.client.callResult(ctx, "sharing.smb.presets", nil, &presets)
+	return presets, err
+}
+
+// GetACL returns the share level ACL of the share named shareName.
+func (s *SMBShares) GetACL(ctx context.Context, shareName string) (*SMBShareACL, error) {
+	var acl SMBShareACL
+	params := map[string]interface{}{"share_name": shareName}
+	if err := s.client.callResult(ctx, "sharing.smb.getacl", []interface{}{params}, &acl); err != nil {
+		return nil, err
+	}
+	return &acl, nil
+}
+
+// SetACL replaces the share level ACL of acl.ShareName.
+func (s *SMBShares) SetACL(ctx context.Context, acl SMBShareACL) (*SMBShareACL, error) {
+	var result SMBShareACL
+	if err := s.client.callResult(ctx, "sharing.smb.setacl", []interface{}{acl}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GrantACL adds entry to the share ACL, replacing any entry for the same principal.
+func (s *SMBShares) GrantACL(ctx context.Context, shareName string, entry SMBShareACLEntry) (*SMBShareACL, error) {
+	acl, err := s.GetACL(ctx, shareName)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []SMBShareACLEntry{entry}
+	for _, existing := range acl.ShareACL {
+		if !existing.samePrincipal(entry) {
+			entries = append(entries, existing)
+		}
+	}
+	acl.ShareACL = entries
+	return s.SetACL(ctx, *acl)
+}
+
+// RevokeACL removes the entries for the principal of entry from the share ACL.
+func (s *SMBShares) RevokeACL(ctx context.Context, shareName string, entry SMBShareACLEntry) (*SMBShareACL, error) {
+	acl, err := s.GetACL(ctx, shareName)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []SMBShareACLEntry{}
+	for _, existing := range acl.ShareACL {
+		if !existing.samePrincipal(entry) {
+			entries = append(entries, existing)
+		}
+	}
+	if len(entries) == len(acl.ShareACL) {
+		return nil, fmt.Errorf("share %s has no ACL entry for the principal", shareName)
+	}
+	acl.ShareACL = entries
+	return s.SetACL(ctx, *acl)
+}
+
+// Config returns the SMB service configuration.
+func (s *SMBShares) Config(ctx context.Context) (*SMBConfig, error) {
+	var config SMBConfig
+	if err := s.client.callResult(ctx, "smb.config", nil, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UpdateConfig replaces the SMB service configuration.
+func (s *SMBShares) UpdateConfig(ctx context.Context, req SMBConfigRequest) (*SMBConfig, error) {
+	var config SMBConfig
+	if err := s.client.callResult(ctx, "smb.update", []interface{}{req}, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
@@ -0,0 +1,113 @@
+package truenas_api
+
+import (
+	"context"
+)
+
+// SnapshotTask is a periodic snapshot task as returned by pool.snapshottask.query.
+type SnapshotTask struct {
+	ID            int               `json:"id"`
+	Dataset       string            `json:"dataset"`
+	Recursive     bool              `json:"recursive"`
+	Exclude       []string          `json:"exclude"`
+	LifetimeValue int               `json:"lifetime_value"`
+	LifetimeUnit  string            `json:"lifetime_unit"` // "HOUR", "DAY", "WEEK", "MONTH" or "YEAR"
+	NamingSchema  string            `json:"naming_schema"` // strftime pattern, e.g. "auto-%Y-%m-%d_%H-%M"
+	Schedule      Schedule          `json:"schedule"`
+	AllowEmpty    bool              `json:"allow_empty"`
+	Enabled       bool              `json:"enabled"`
+	VMwareSync    bool              `json:"vmware_sync"`
+	State         SnapshotTaskState `json:"state"`
+}
+
+// SnapshotTaskState is the state of the last run of a periodic snapshot task.
+type SnapshotTaskState struct {
+	State    string    `json:"state"` // "PENDING", "RUNNING", "FINISHED" or "ERROR"
+	Datetime *DateTime `json:"datetime"`
+	Error    string    `json:"error"`
+	Warnings []string  `json:"warnings"`
+}
+
+// SnapshotTaskRequest holds the writable fields of a periodic snapshot task.
+type SnapshotTaskRequest struct {
+	Dataset       string   `json:"dataset"`
+	Recursive     bool     `json:"recursive"`
+	Exclude       []string `json:"exclude"`
+	LifetimeValue int      `json:"lifetime_value"`
+	LifetimeUnit  string   `json:"lifetime_unit"`
+	NamingSchema  string   `json:"naming_schema"`
+	Schedule      Schedule `json:"schedule"`
+	AllowEmpty    bool     `json:"allow_empty"`
+	Enabled       bool     `json:"enabled"`
+}
+
+// Request returns the writable fields of the task, to be modified and passed to Update.
+func (t SnapshotTask) Request() SnapshotTaskRequest {
+	return SnapshotTaskRequest{
+		Dataset:       t.Dataset,
+		Recursive:     t.Recursive,
+		Exclude:       t.Exclude,
+		LifetimeValue: t.LifetimeValue,
+		LifetimeUnit:  t.LifetimeUnit,
+		NamingSchema:  t.NamingSchema,
+		Schedule:      t.Schedule,
+		AllowEmpty:    t.AllowEmpty,
+		Enabled:       t.Enabled,
+	}
+}
+
+// SnapshotTasks provides typed access to the pool.snapshottask.* namespace.
+type SnapshotTasks struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewSnapshotTasks creates a new SnapshotTasks service.
+func NewSnapshotTasks(client *Client) *SnapshotTasks {
+	return &SnapshotTasks{client: client}
+}
+
+// Query returns the periodic snapshot tasks matching filters.
+func (s *SnapshotTasks) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]SnapshotTask, error) {
+	var tasks []SnapshotTask
+	err := s.client.callResult(ctx, "pool.snapshottask.query", queryParams(filters, opts), &tasks)
+	return tasks, err
+}
+
+// Get returns a single periodic snapshot task by ID.
+func (s *SnapshotTasks) Get(ctx context.Context, id int) (*SnapshotTask, error) {
+	var task SnapshotTask
+	if err := s.client.callResult(ctx, "pool.snapshottask.get_instance", []interface{}{id}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Create creates a periodic snapshot task.
+func (s *SnapshotTasks) Create(ctx context.Context, req SnapshotTaskRequest) (*SnapshotTask, error) {
+	var task SnapshotTask
+	if err := s.client.callResult(ctx, "pool.snapshottask.create", []interface{}{req}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Update replaces the writable fields of a periodic snapshot task.
+func (s *SnapshotTasks) Update(ctx context.Context, id int, req SnapshotTaskRequest) (*SnapshotTask, error) {
+	var task SnapshotTask
+	if err := s.client.callResult(ctx, "pool.snapshottask.update", []interface{}{id, req}, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// Delete deletes a periodic snapshot task. fixateRemovalDate keeps the retention
+// of the snapshots it took, so that they are still removed on schedule.
+func (s *SnapshotTasks) Delete(ctx context.Context, id int, fixateRemovalDate bool) error {
+	opts := map[string]interface{}{"fixate_removal_date": fixateRemovalDate}
+	return s.client.callResult(ctx, "pool.snapshottask.delete", []interface{}{id, opts}, nil)
+}
+
+// Run takes a snapshot for the task immediately.
+func (s *SnapshotTasks) Run(ctx context.Context, id int) error {
+	return s.client.callResult(ctx, "pool.snapshottask.run", []interface{}{id}, nil)
+}
@@ -0,0 +1,351 @@
+package truenas_api
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// System states returned by System.State.
+const (
+	SystemBooting      = "BOOTING"
+	SystemReady        = "READY"
+	SystemShuttingDown = "SHUTTING_DOWN"
+)
+
+// SystemInfo is the result of system.info.
+type SystemInfo struct {
+	Version              string     `json:"version"`
+	Buildtime            *DateTime  `json:"buildtime"`
+	Hostname             string     `json:"hostname"`
+	Physmem              int64      `json:"physmem"` // Physical memory in bytes
+	Model                string     `json:"model"`   // CPU model
+	Cores                int        `json:"cores"`
+	PhysicalCores        int        `json:"physical_cores"`
+	Loadavg              [3]float64 `json:"loadavg"`
+	Uptime               string     `json:"uptime"`
+	UptimeSeconds        float64    `json:"uptime_seconds"`
+	SystemSerial         string     `json:"system_serial"`
+	SystemProduct        string     `json:"system_product"`
+	SystemProductVersion string     `json:"system_product_version"`
+	SystemManufacturer   string     `json:"system_manufacturer"`
+	Boottime             *DateTime  `json:"boottime"`
+	Datetime             *DateTime  `json:"datetime"`
+	Timezone             string     `json:"timezone"`
+	ECCMemory            bool       `json:"ecc_memory"`
+}
+
+// System provides typed access to the system.* namespace.
+type System struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewSystem creates a new System service.
+func NewSystem(client *Client) *System {
+	return &System{client: client}
+}
+
+// Info returns general information about the system.
+func (s *System) Info(ctx context.Context) (*SystemInfo, error) {
+	var info SystemInfo
+	if err := s.client.callResult(ctx, "system.info", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Version returns the full version string, e.g. "TrueNAS-SCALE-24.10.2".
+func (s *System) Version(ctx context.Context) (string, error) {
+	var version string
+	err := s.client.callResult(ctx, "system.version", nil, &version)
+	return version, err
+}
+
+// Ready reports whether the system finished booting.
+func (s *System) Ready(ctx context.Context) (bool, error) {
+	var ready bool
+	err := s.client.callResult(ctx, "system.ready", nil, &ready)
+	return ready, err
+}
+
+// State returns one of the System* states.
+func (s *System) State(ctx context.Context) (string, error) {
+	var state string
+	err := s.client.callResult(ctx, "system.state", nil, &state)
+	return state, err
+}
+
+// BootID returns an ID that changes on every boot.
+func (s *System) BootID(ctx context.Context) (string, error) {
+	var bootID string
+	err := s.client.callResult(ctx, "system.boot_id", nil, &bootID)
+	return bootID, err
+}
+
+// Reboot reboots the system, logging reason. It returns once the reboot has
+// been initiated, see RebootAndWait to wait for the system to come back.
+func (s *System) Reboot(ctx context.Context, reason string) error {
+	return s.power(ctx, "system.reboot", reason)
+}
+
+// Shutdown powers off the system, logging reason.
+func (s *System) Shutdown(ctx context.Context, reason string) error {
+	return s.power(ctx, "system.shutdown", reason)
+}
+
+// power calls system.reboot or system.shutdown and waits for the job, see awaitPowerJob.
+func (s *System) power(ctx context.Context, method, reason string) error {
+	bootID, err := s.BootID(ctx)
+	if err != nil {
+		return err
+	}
+	job, err := s.client.callJob(ctx, method, []interface{}{reason}, nil)
+	if err != nil {
+		return err
+	}
+	return s.awaitPowerJob(ctx, job, bootID, method == "system.shutdown")
+}
+
+// awaitPowerJob waits for job, which reboots the system, or shuts it down if
+// shutdown is set, once it succeeds. The job usually never reports back as the
+// connection drops first, so its outcome is confirmed after reconnecting: on a
+// boot other than oldBootID the job succeeded, and on the old boot the job is
+// waited for as before. A system that is shutting down counts as shut down
+// once it can no longer be reached.
+func (s *System) awaitPowerJob(ctx context.Context, job *Job, oldBootID string, shutdown bool) error {
+	delay := time.Second
+	for {
+		select {
+		case <-job.DoneCh:
+			if job.Error != "" {
+				return fmt.Errorf("job %d (%s) failed: %s", job.ID, job.Method, job.Error)
+			}
+			return nil
+		case <-s.client.closed():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		done, err := s.reconnectAfterJob(ctx, job, oldBootID, shutdown)
+		if done {
+			return nil
+		}
+		if err == nil {
+			continue // Back on the old boot, wait for the job again
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("job %d (%s) not confirmed: %w", job.ID, job.Method, errors.Join(ctx.Err(), err))
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+	}
+}
+
+// reconnectAfterJob reconnects after the connection dropped while waiting for
+// job and reports whether the job is known to have succeeded: the system runs
+// on a boot other than oldBootID, or, with shutdown set, can't be reconnected
+// to. On the old boot it catches up on the updates of job missed while
+// disconnected.
+func (s *System) reconnectAfterJob(ctx context.Context, job *Job, oldBootID string, shutdown bool) (bool, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := s.client.Reconnect(attemptCtx); err != nil {
+		return shutdown, err
+	}
+	bootID, err := s.BootID(attemptCtx)
+	if err != nil {
+		return false, err
+	}
+	if bootID != oldBootID {
+		return true, nil
+	}
+	s.client.refreshJob(attemptCtx, job.ID)
+	return false, nil
+}
+
+// SaveConfig writes a backup of the system configuration to w. Without the
+// secret seed the backup is the configuration database; with it, it is a tar
+// archive that also holds the seed needed to decrypt stored passwords and keys.
+func (s *System) SaveConfig(ctx context.Context, w io.Writer, includeSecretSeed bool) error {
+	filename := "truenas-config.db"
+	if includeSecretSeed {
+		filename = "truenas-config.tar"
+	}
+	options := map[string]interface{}{"secretseed": includeSecretSeed}
+	return s.client.Download(ctx, "config.save", []interface{}{options}, filename, w)
+}
+
+// UploadConfig restores a configuration backup written by SaveConfig from r.
+// The system reboots to apply it, so UploadConfig returns once the upload job
+// finished or the system rebooted, see UploadConfigAndWait to wait for the
+// system to come back.
+func (s *System) UploadConfig(ctx context.Context, r io.Reader) error {
+	bootID, err := s.BootID(ctx)
+	if err != nil {
+		return err
+	}
+	return s.uploadConfig(ctx, r, bootID)
+}
+
+// UploadConfigAndWait restores a configuration backup like UploadConfig and
+// waits until the system is back up on the new boot, see WaitUntilReady.
+func (s *System) UploadConfigAndWait(ctx context.Context, r io.Reader) error {
+	bootID, err := s.BootID(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.uploadConfig(ctx, r, bootID); err != nil {
+		return err
+	}
+	return s.waitForBoot(ctx, bootID)
+}
+
+// uploadConfig implements UploadConfig, oldBootID is the boot before the upload.
+func (s *System) uploadConfig(ctx context.Context, r io.Reader, oldBootID string) error {
+	job, err := s.client.Upload(ctx, "config.upload", nil, "truenas-config", r, nil)
+	if err != nil {
+		return err
+	}
+	return s.awaitPowerJob(ctx, job, oldBootID, false)
+}
+
+// Debug generates a debug archive for support tickets with system.debug and
+// writes it to w, reporting the progress of the job to callback, and returns
+// its size. Neither the job nor the download reports the size of the archive
+// up front, and the download is usually sent without a Content-Length, so the
+// archive is instead checked to be complete by reading it to its end while it
+// is written: a truncated gzip or tar stream fails.
+func (s *System) Debug(ctx context.Context, w io.Writer, callback JobCallback) (int64, error) {
+	pr, pw := io.Pipe()
+	verified := make(chan error, 1)
+	go func() {
+		err := verifyArchive(pr)
+		io.Copy(io.Discard, pr) // Keep the download going after an error or trailing padding
+		verified <- err
+	}()
+
+	n, err := s.client.download(ctx, "system.debug", nil, "debug.tgz", io.MultiWriter(w, pw), callback)
+	pw.CloseWithError(err)
+	verifyErr := <-verified
+	if err != nil {
+		return n, err
+	}
+	if n == 0 {
+		return 0, errors.New("debug archive is empty")
+	}
+	if verifyErr != nil {
+		return n, fmt.Errorf("debug archive is incomplete: %w", verifyErr)
+	}
+	return n, nil
+}
+
+// verifyArchive reads a tar archive, optionally gzip compressed, to its end.
+func verifyArchive(r io.Reader) error {
+	br := bufio.NewReader(r)
+	var archive io.Reader = br
+	var gz *gzip.Reader
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		var err error
+		if gz, err = gzip.NewReader(br); err != nil {
+			return err
+		}
+		archive = gz
+	}
+
+	tr := tar.NewReader(archive)
+	for {
+		if _, err := tr.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	if gz != nil {
+		// Read up to the gzip trailer, which verifies the checksum
+		if _, err := io.Copy(io.Discard, gz); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebootAndWait reboots the system and waits until it is back up on the new
+// boot, see WaitUntilReady.
+func (s *System) RebootAndWait(ctx context.Context, reason string) error {
+	bootID, err := s.BootID(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.Reboot(ctx, reason); err != nil {
+		return err
+	}
+	return s.waitForBoot(ctx, bootID)
+}
+
+// WaitUntilReady returns once system.ready is true. If the connection drops,
+// e.g. during a reboot, it reconnects with exponential backoff and logs in
+// again with the credentials of the last successful Login.
+func (s *System) WaitUntilReady(ctx context.Context) error {
+	return s.waitForBoot(ctx, "")
+}
+
+// waitForBoot retries with backoff until the system runs with a boot ID other
+// than oldBootID, if set, and system.ready returns true.
+func (s *System) waitForBoot(ctx context.Context, oldBootID string) error {
+	delay := time.Second
+	for {
+		err := s.checkBoot(ctx, oldBootID)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("system not ready: %w", errors.Join(ctx.Err(), err))
+		}
+		if delay *= 2; delay > 30*time.Second {
+			delay = 30 * time.Second
+		}
+	}
+}
+
+// checkBoot returns nil if the system is ready on a boot other than oldBootID,
+// reconnecting first if the connection is gone.
+func (s *System) checkBoot(ctx context.Context, oldBootID string) error {
+	attemptCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	bootID, err := s.BootID(attemptCtx)
+	if err != nil {
+		if err := s.client.Reconnect(attemptCtx); err != nil {
+			return err
+		}
+		if bootID, err = s.BootID(attemptCtx); err != nil {
+			return err
+		}
+	}
+	if oldBootID != "" && bootID == oldBootID {
+		return errors.New("system has not rebooted yet")
+	}
+
+	ready, err := s.Ready(attemptCtx)
+	if err != nil {
+		return err
+	}
+	if !ready {
+		return errors.New("system is booting")
+	}
+	return nil
+}
@@ -1,31 +1,88 @@
 package truenas_api
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// ErrClosed is returned by calls made on, or interrupted by, a closed connection.
+var ErrClosed = errors.New("connection closed")
+
 // Client encapsulates the connection to the WebSocket server.
 type Client struct {
-	url        string                       // WebSocket server URL
-	conn       *websocket.Conn              // WebSocket connection instance
-	mu         sync.Mutex                   // Mutex for ensuring thread-safety
-	isClosed   bool                         // Indicates if the connection is closed
-	callID     int                          // Unique ID for tracking each call
-	pending    map[int]chan json.RawMessage // Stores pending calls, maps call IDs to response channels
-	notifyChan chan os.Signal               // For handling notifications (e.g., OS signals)
-	closeChan  chan struct{}                // Channel to signal when the connection should be closed
-	jobs       *Jobs                        // Jobs manager to track long-running jobs
+	url            string                       // WebSocket server URL
+	verifySSL      bool                         // Indicates if SSL certificates are verified for wss connections
+	conn           *websocket.Conn              // WebSocket connection instance
+	mu             sync.Mutex                   // Mutex for ensuring thread-safety
+	writeMu        sync.Mutex                   // Serializes writes to the WebSocket connection
+	isClosed       bool                         // Indicates if the connection is closed
+	callID         int                          // Unique ID for tracking each call
+	pending        map[int]chan json.RawMessage // Stores pending calls, maps call IDs to response channels
+	notifyChan     chan os.Signal               // For handling notifications (e.g., OS signals)
+	closeChan      chan struct{}                // Channel to signal when the connection should be closed
+	jobs           *Jobs                        // Jobs manager to track long-running jobs
+	jobsSubscribed bool                         // Indicates if job updates are already subscribed to
+	subs           map[*subscription]bool       // Active event subscriptions
+	credentials    *credentials                 // Credentials of the last successful Login, used to reconnect
+	validate       bool                         // Validate params against the method schemas before sending, see WithValidation
+	validator      validator                    // Cached method schemas, loaded by Login
+	validatorMu    sync.Mutex                   // Guards validator
+}
+
+// ClientOption configures a Client, see NewClient.
+type ClientOption func(*Client)
+
+// WithValidation has the client validate params locally against the method
+// schemas of core.get_methods, loaded once by the first successful Login.
+// Invalid params fail with a *ValidationError without a round trip. If the
+// schemas could not be loaded, Login returns ErrSchemasUnavailable and calls
+// are sent unvalidated until a later Login loads them.
+func WithValidation() ClientOption {
+	return func(c *Client) {
+		c.validate = true
+	}
+}
+
+// credentials are the arguments of a successful Login.
+type credentials struct {
+	username, password, apiKey string
+}
+
+// APIError is the error object of a failed JSON-RPC call.
+type APIError struct {
+	Code    int           `json:"code"`           // JSON-RPC error code
+	Message string        `json:"message"`        // JSON-RPC error message
+	Data    *APIErrorData `json:"data,omitempty"` // Middleware specific error details
+}
+
+// APIErrorData holds the middleware specific details of an APIError.
+type APIErrorData struct {
+	Error   int             `json:"error"`   // errno value of the error
+	ErrName string          `json:"errname"` // errno name of the error (e.g., "EINVAL")
+	Reason  string          `json:"reason"`  // Human readable reason
+	Extra   json.RawMessage `json:"extra"`   // Additional error details (e.g., validation errors)
 }
 
+func (e *APIError) Error() string {
+	if e.Data != nil && e.Data.Reason != "" {
+		return "API error: " + e.Data.Reason
+	}
+	return fmt.Sprintf("API error: %d %s", e.Code, e.Message)
+}
+
+// JobCallback is called with progress updates of a tracked job.
+type JobCallback func(progress float64, state string, desc string)
+
 // Job represents a long-running job in TrueNAS.
 type Job struct {
 	ID         int64                                             // Job ID
@@ -34,6 +91,7 @@ type Job struct {
 	Result     interface{}                                       // Result of the job once it finishes
 	Progress   float64                                           // Progress of the job (0.0 to 100.0)
 	Finished   bool                                              // Indicates if the job is finished
+	Error      string                                            // Error message of the job if it failed
 	ProgressCh chan float64                                      // Channel to report progress updates
 	DoneCh     chan string                                       // Channel to signal when the job is done
 	Callback   func(progress float64, state string, desc string) // Callback function to report progress and state
@@ -80,7 +138,7 @@ func (j *Jobs) AddJob(jobID int64, method string) *Job {
 		Method:     method,
 		State:      "PENDING",
 		ProgressCh: make(chan float64),
-		DoneCh:     make(chan string),
+		DoneCh:     make(chan string, 1), // Buffered so finishing never blocks on an absent reader
 	}
 	j.jobs[jobID] = job // Add job to jobs map
 	return job
@@ -103,24 +161,75 @@ func (j *Jobs) RemoveJob(jobID int64) {
 
 // UpdateJobState updates the state of a long-running job.
 func (j *Jobs) UpdateJobState(jobID int64, state string, progress float64, result interface{}, err string) {
+	j.update(jobID, state, progress, result, err)
+}
+
+// update implements UpdateJobState and reports whether the update was applied.
+func (j *Jobs) update(jobID int64, state string, progress float64, result interface{}, err string) bool {
 	j.mu.Lock()
 	defer j.mu.Unlock()
 	job, exists := j.jobs[jobID]
 	if !exists {
-		return // If the job doesn't exist, return
+		return false // If the job doesn't exist, return
+	}
+	if job.Finished {
+		return false // Ignore updates that arrive after the job finished
 	}
 	job.State = state
 	job.Progress = progress
-	if state == "SUCCESS" || state == "FAILED" {
+	select {
+	case job.ProgressCh <- progress: // Report progress to a waiting reader, never block the listener
+	default:
+	}
+	if state == "SUCCESS" || state == "FAILED" || state == "ABORTED" {
 		job.Finished = true
 		job.Result = result
+		job.Error = err
+		if state != "SUCCESS" && err == "" {
+			job.Error = "job " + strings.ToLower(state)
+			err = job.Error
+		}
 		job.DoneCh <- err     // Send error (if any) to the done channel
 		close(job.ProgressCh) // Close progress channel after job completion
 		close(job.DoneCh)     // Close done channel after job completion
 	}
+	return true
 }
 
+// Wait blocks until the job finishes or ctx is done and returns the job error, if any.
+func (j *Job) Wait(ctx context.Context) error {
+	select {
+	case <-j.DoneCh:
+		if j.Error != "" {
+			return fmt.Errorf("job %d (%s) failed: %s", j.ID, j.Method, j.Error)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DecodeResult decodes the result of a finished job into out.
+func (j *Job) DecodeResult(out interface{}) error {
+	data, err := json.Marshal(j.Result)
+	if err != nil {
+		return fmt.Errorf("failed to encode job result: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse job result: %w", err)
+	}
+	return nil
+}
+
+// SubscribeToJobs subscribes to job updates. Calling it more than once is a no-op.
 func (c *Client) SubscribeToJobs() error {
+	c.mu.Lock()
+	subscribed := c.jobsSubscribed
+	c.mu.Unlock()
+	if subscribed {
+		return nil
+	}
+
 	params := []interface{}{"core.get_jobs"} // Core function to subscribe to job updates
 
 	// Make the subscription call via WebSocket
@@ -135,67 +244,179 @@ func (c *Client) SubscribeToJobs() error {
 		return fmt.Errorf("failed to parse subscription response: %w", err)
 	}
 
+	c.mu.Lock()
+	c.jobsSubscribed = true
+	c.mu.Unlock()
+
 	return nil
 }
 
 // NewClient creates a new WebSocket client connection.
-func NewClient(serverURL string, verifySSL bool) (*Client, error) {
+func NewClient(serverURL string, verifySSL bool, opts ...ClientOption) (*Client, error) {
+	return newClient(context.Background(), serverURL, verifySSL, opts...)
+}
+
+// newClient implements NewClient, dialing until ctx is done.
+func newClient(ctx context.Context, serverURL string, verifySSL bool, opts ...ClientOption) (*Client, error) {
+	conn, err := dial(ctx, serverURL, verifySSL)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{
+		url:       serverURL,
+		verifySSL: verifySSL,
+		conn:      conn,
+		pending:   make(map[int]chan json.RawMessage),
+		closeChan: make(chan struct{}),
+		jobs:      NewJobs(nil),
+		subs:      make(map[*subscription]bool),
+	}
+
+	client.jobs = NewJobs(client)
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	go client.listen(conn, client.closeChan) // Start listening for WebSocket messages
+
+	return client, nil
+}
+
+// dial establishes a WebSocket connection to serverURL.
+func dial(ctx context.Context, serverURL string, verifySSL bool) (*websocket.Conn, error) {
 	u, err := url.Parse(serverURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %w", err)
 	}
 
 	// Configure WebSocket connection options
-	dialer := websocket.DefaultDialer
+	dialer := *websocket.DefaultDialer
 	if u.Scheme == "wss" && !verifySSL {
 		dialer.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // Disable SSL verification for wss
 	}
 
 	// Establish the WebSocket connection
-	conn, _, err := dialer.Dial(u.String(), nil)
+	conn, _, err := dialer.DialContext(ctx, u.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
+	return conn, nil
+}
 
-	client := &Client{
-		url:       serverURL,
-		conn:      conn,
-		pending:   make(map[int]chan json.RawMessage),
-		closeChan: make(chan struct{}),
-		jobs:      NewJobs(nil),
+// URL returns the WebSocket server URL the client is connected to.
+func (c *Client) URL() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.url
+}
+
+// closed returns a channel that is closed when the current connection closes.
+func (c *Client) closed() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeChan
+}
+
+// Reconnect replaces the connection with a new one to the same server.
+// See ReconnectTo.
+func (c *Client) Reconnect(ctx context.Context) error {
+	return c.ReconnectTo(ctx, c.URL())
+}
+
+// ReconnectTo replaces the connection with a new one to serverURL and logs in
+// again with the credentials of the last successful Login, both until ctx is
+// done. Pending calls and event subscriptions of the old connection end with
+// ErrClosed; tracked jobs keep receiving updates.
+func (c *Client) ReconnectTo(ctx context.Context, serverURL string) error {
+	conn, err := dial(ctx, serverURL, c.verifySSL)
+	if err != nil {
+		return err
 	}
+	c.Close() // Ends the old listener, pending calls and subscriptions
 
-	client.jobs = NewJobs(client)
+	c.mu.Lock()
+	c.writeMu.Lock()
+	c.url = serverURL
+	c.conn = conn
+	c.isClosed = false
+	c.closeChan = make(chan struct{})
+	c.pending = make(map[int]chan json.RawMessage)
+	resubscribe := c.jobsSubscribed
+	c.jobsSubscribed = false
+	creds := c.credentials
+	closeChan := c.closeChan
+	c.writeMu.Unlock()
+	c.mu.Unlock()
 
-	go client.listen() // Start listening for WebSocket messages
+	go c.listen(conn, closeChan)
 
-	return client, nil
+	if creds != nil {
+		if err := c.login(ctx, creds.username, creds.password, creds.apiKey); err != nil && !errors.Is(err, ErrSchemasUnavailable) {
+			return err
+		}
+	}
+	if resubscribe {
+		if err := c.SubscribeToJobs(); err != nil {
+			return fmt.Errorf("failed to resubscribe to job updates: %w", err)
+		}
+	}
+	return nil
 }
 
 // Close closes the WebSocket connection.
 func (c *Client) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	return c.closeLocked()
+}
 
+// closeLocked closes the WebSocket connection, c.mu must be held.
+func (c *Client) closeLocked() error {
 	if c.isClosed {
 		return nil // Return if connection is already closed
 	}
 	c.isClosed = true
 	close(c.closeChan) // Signal that the connection is closed
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
 	err := c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-	if err != nil {
-		return err
+	if closeErr := c.conn.Close(); err == nil { // Close the actual WebSocket connection
+		err = closeErr
 	}
-	return c.conn.Close() // Close the actual WebSocket connection
+	return err
 }
 
 // Call sends an RPC call to the server and waits for a response.
 func (c *Client) Call(method string, timeout time.Duration, params interface{}) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout*time.Second)
+	defer cancel()
+
+	res, err := c.CallContext(ctx, method, params)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, errors.New("call timed out")
+	}
+	return res, err
+}
+
+// CallContext sends an RPC call to the server and waits for a response until ctx is done.
+func (c *Client) CallContext(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	if c.validate {
+		if err := c.validateParams(ctx, method, params); err != nil {
+			return nil, err
+		}
+	}
+
 	c.mu.Lock()
+	if c.isClosed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
 	c.callID++ // Increment callID for each call
 	callID := c.callID
 	responseChan := make(chan json.RawMessage, 1) // Create channel to receive the response
 	c.pending[callID] = responseChan              // Store the callID and response channel
+	closeChan := c.closeChan
 	c.mu.Unlock()
 
 	defer func() {
@@ -213,32 +434,144 @@ func (c *Client) Call(method string, timeout time.Duration, params interface{})
 	}
 
 	// Send the request to the WebSocket server
-	if err := c.conn.WriteJSON(request); err != nil {
+	if err := c.writeJSON(request); err != nil {
 		return nil, fmt.Errorf("failed to send call: %w", err)
 	}
 
-	// Wait for the response or timeout
+	// Wait for the response, the connection to close or the context to end
 	select {
 	case res := <-responseChan:
 		return res, nil
-	case <-time.After(timeout * time.Second):
-		return nil, errors.New("call timed out")
+	case <-closeChan:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// writeJSON writes a message to the WebSocket connection, one writer at a time.
+func (c *Client) writeJSON(v interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+// callResult sends an RPC call and decodes the result into out, which may be nil.
+// An error reported by the server is returned as an *APIError.
+func (c *Client) callResult(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	res, err := c.CallContext(ctx, method, params)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *APIError       `json:"error"`
+	}
+	if err := json.Unmarshal(res, &response); err != nil {
+		return fmt.Errorf("failed to parse %s response: %w", method, err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("%s: %w", method, response.Error)
+	}
+
+	if out != nil && len(response.Result) > 0 {
+		if err := json.Unmarshal(response.Result, out); err != nil {
+			return fmt.Errorf("failed to parse %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// CallResult sends an RPC call and decodes the result into out, which may be
+// nil, for methods not covered by the typed services. An error reported by the
+// server is returned as an *APIError.
+func (c *Client) CallResult(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	return c.callResult(ctx, method, params, out)
+}
+
+// CallJob starts a method that returns a job ID and tracks the job like
+// CallWithJob, with a context and the params as a list.
+func (c *Client) CallJob(ctx context.Context, method string, params []interface{}, callback JobCallback) (*Job, error) {
+	return c.callJob(ctx, method, params, callback)
+}
+
+// callJob starts a method that returns a job ID and tracks the job, subscribing to job updates first if needed.
+func (c *Client) callJob(ctx context.Context, method string, params []interface{}, callback JobCallback) (*Job, error) {
+	if err := c.SubscribeToJobs(); err != nil {
+		return nil, err
+	}
+
+	var jobID int64
+	if err := c.callResult(ctx, method, params, &jobID); err != nil {
+		return nil, err
+	}
+	return c.trackJob(ctx, jobID, method, callback), nil
+}
+
+// trackJob registers a job started by this client with the Jobs manager.
+// Updates that arrived before the job was registered were dropped by listen,
+// so the current state of the job is fetched once and applied; a job that
+// already finished is reported as finished.
+func (c *Client) trackJob(ctx context.Context, jobID int64, method string, callback JobCallback) *Job {
+	job := c.jobs.AddJob(jobID, method)
+	job.Callback = callback
+	c.jobs.AddOwnedJob(jobID)
+	c.refreshJob(ctx, jobID)
+	return job
+}
+
+// refreshJob fetches the current state of a tracked job and applies it, to
+// catch up on updates that were missed.
+func (c *Client) refreshJob(ctx context.Context, jobID int64) {
+	var jobs []map[string]interface{}
+	filters := []interface{}{[]interface{}{"id", "=", jobID}}
+	if err := c.callResult(ctx, "core.get_jobs", []interface{}{filters}, &jobs); err == nil && len(jobs) == 1 {
+		c.updateJob(jobID, jobs[0])
+	}
+}
+
+// updateJob applies the fields of a job, from a core.get_jobs event or query,
+// to an owned job and reports the progress to its callback.
+func (c *Client) updateJob(jobID int64, fields map[string]interface{}) {
+	progress, _ := fields["progress"].(map[string]interface{})
+	description, _ := progress["description"].(string)
+	percent, _ := progress["percent"].(float64)
+	state, _ := fields["state"].(string)
+	result := fields["result"]
+	errors, _ := fields["error"].(string)
+
+	// Update the job state in the Jobs manager
+	if !c.jobs.update(jobID, state, percent, result, errors) {
+		return
+	}
+
+	// Trigger the callback if it exists
+	if job, exists := c.jobs.GetJob(jobID); exists && job.Callback != nil {
+		job.Callback(percent, state, description)
 	}
 }
 
-// listen listens for incoming WebSocket messages.
-func (c *Client) listen() {
+// listen listens for incoming WebSocket messages on conn until closeChan is closed.
+func (c *Client) listen(conn *websocket.Conn, closeChan chan struct{}) {
 	for {
 		select {
-		case <-c.closeChan: // If the connection is closed, stop listening
+		case <-closeChan: // If the connection is closed, stop listening
 			return
 		default:
-			_, message, err := c.conn.ReadMessage() // Read message from WebSocket server
+			_, message, err := conn.ReadMessage() // Read message from WebSocket server
 			if err != nil {
 				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
 					// log.Printf("error reading message: %v", err) // Log any non-close error
 				}
-				c.Close()
+				c.mu.Lock()
+				if c.conn == conn { // The connection may already have been replaced by ReconnectTo
+					c.closeLocked()
+				}
+				c.mu.Unlock()
 				return
 			}
 
@@ -249,26 +582,21 @@ func (c *Client) listen() {
 
 			// Handle collection update (e.g., job progress updates)
 			if method, ok := response["method"].(string); ok && method == "collection_update" {
-				params := response["params"].(map[string]interface{})
-				jobID := int64(params["id"].(float64))
-				fields := params["fields"].(map[string]interface{})
+				params, _ := response["params"].(map[string]interface{})
+
+				// Events other than job updates go to their subscribers
+				if collection, _ := params["collection"].(string); collection != "" && collection != "core.get_jobs" {
+					c.dispatchEvent(message)
+					continue
+				}
+
+				id, _ := params["id"].(float64)
+				jobID := int64(id)
+				fields, _ := params["fields"].(map[string]interface{})
 
 				// Only handle jobs started by this client
 				if c.jobs.IsOwnedJob(jobID) {
-					progress := fields["progress"].(map[string]interface{})
-					description, _ := progress["description"].(string)
-					percent, _ := progress["percent"].(float64)
-					state, _ := fields["state"].(string)
-					result, _ := fields["result"].(string)
-					errors, _ := fields["error"].(string)
-
-					// Update the job state in the Jobs manager
-					c.jobs.UpdateJobState(jobID, state, percent, result, errors)
-
-					// Trigger the callback if it exists
-					if job, exists := c.jobs.jobs[jobID]; exists && job.Callback != nil {
-						job.Callback(percent, state, description)
-					}
+					c.updateJob(jobID, fields)
 				}
 				continue
 			}
@@ -310,17 +638,10 @@ func (c *Client) CallWithJob(method string, params interface{}, callback func(pr
 		return nil, fmt.Errorf("unexpected response format for job")
 	}
 
-	// Add the job to the Jobs manager
-	job := c.jobs.AddJob(int64(jobID), method)
-
-	// Mark this job as owned by this client
-	c.jobs.AddOwnedJob(int64(jobID))
-
-	// Set the callback function for job updates
-	job.Callback = callback
-
-	// Return the Job instance to allow tracking
-	return job, nil
+	// Add the job to the Jobs manager, mark it as owned by this client and catch up on its state
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return c.trackJob(ctx, int64(jobID), method, callback), nil
 }
 
 // Ping sends a ping request to the server to check connectivity.
@@ -347,6 +668,13 @@ func (c *Client) Ping() (string, error) {
 
 // Login attempts to log in using either username/password or an API key.
 func (c *Client) Login(username, password, apiKey string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return c.login(ctx, username, password, apiKey)
+}
+
+// login implements Login, waiting for the response until ctx is done.
+func (c *Client) login(ctx context.Context, username, password, apiKey string) error {
 	var params interface{}
 	var method string
 
@@ -363,7 +691,10 @@ func (c *Client) Login(username, password, apiKey string) error {
 	}
 
 	// Make the login call
-	res, err := c.Call(method, 10, params)
+	res, err := c.CallContext(ctx, method, params)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = errors.New("call timed out")
+	}
 	if err != nil {
 		return fmt.Errorf("login failed: %w", err)
 	}
@@ -380,6 +711,12 @@ func (c *Client) Login(username, password, apiKey string) error {
 
 	// Return success if login result is true
 	if result, exists := response["result"]; exists && result == true {
+		c.mu.Lock()
+		c.credentials = &credentials{username: username, password: password, apiKey: apiKey}
+		c.mu.Unlock()
+		if c.validate {
+			return c.loadSchemas()
+		}
 		return nil
 	}
 
@@ -0,0 +1,83 @@
+package truenas_api
+
+import (
+	"context"
+)
+
+// Tunable types.
+const (
+	TunableSysctl = "SYSCTL"
+	TunableUdev   = "UDEV"
+	TunableZFS    = "ZFS"
+)
+
+// Tunable is a system tunable as returned by tunable.query.
+type Tunable struct {
+	ID        int    `json:"id"`
+	Type      string `json:"type"` // One of the Tunable* types
+	Var       string `json:"var"`  // e.g. "net.core.somaxconn" or a ZFS module parameter
+	Value     string `json:"value"`
+	OrigValue string `json:"orig_value"` // Value before the tunable was applied
+	Comment   string `json:"comment"`
+	Enabled   bool   `json:"enabled"`
+}
+
+// TunableRequest holds the writable fields of a tunable. Type and Var are only used on create.
+type TunableRequest struct {
+	Type    string `json:"type,omitempty"`
+	Var     string `json:"var,omitempty"`
+	Value   string `json:"value"`
+	Comment string `json:"comment"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Request returns the writable fields of the tunable, to be modified and passed to Update.
+func (t Tunable) Request() TunableRequest {
+	return TunableRequest{
+		Value:   t.Value,
+		Comment: t.Comment,
+		Enabled: t.Enabled,
+	}
+}
+
+// Tunables provides typed access to the tunable.* namespace.
+type Tunables struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewTunables creates a new Tunables service.
+func NewTunables(client *Client) *Tunables {
+	return &Tunables{client: client}
+}
+
+// Query returns the tunables matching filters.
+func (t *Tunables) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]Tunable, error) {
+	var tunables []Tunable
+	err := t.client.callResult(ctx, "tunable.query", queryParams(filters, opts), &tunables)
+	return tunables, err
+}
+
+// Get returns a single tunable by ID.
+func (t *Tunables) Get(ctx context.Context, id int) (*Tunable, error) {
+	var tunable Tunable
+	if err := t.client.callResult(ctx, "tunable.get_instance", []interface{}{id}, &tunable); err != nil {
+		return nil, err
+	}
+	return &tunable, nil
+}
+
+// Create creates and applies a tunable. The job result is the new tunable.
+func (t *Tunables) Create(ctx context.Context, req TunableRequest, callback JobCallback) (*Job, error) {
+	return t.client.callJob(ctx, "tunable.create", []interface{}{req}, callback)
+}
+
+// Update replaces the writable fields of a tunable and applies it.
+func (t *Tunables) Update(ctx context.Context, id int, req TunableRequest, callback JobCallback) (*Job, error) {
+	req.Type, req.Var = "", "" // Not accepted by tunable.update
+	return t.client.callJob(ctx, "tunable.update", []interface{}{id, req}, callback)
+}
+
+// Delete deletes a tunable, restoring its original value.
+func (t *Tunables) Delete(ctx context.Context, id int, callback JobCallback) (*Job, error) {
+	return t.client.callJob(ctx, "tunable.delete", []interface{}{id}, callback)
+}
@@ -0,0 +1,161 @@
+package truenas_api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateTime is a point in time as encoded by the middleware, {"$date": <milliseconds>}.
+// It also accepts plain RFC 3339 strings and second based UNIX timestamps.
+type DateTime struct {
+	time.Time
+}
+
+// NewDateTime wraps t in a DateTime.
+func NewDateTime(t time.Time) *DateTime {
+	return &DateTime{Time: t}
+}
+
+// MarshalJSON encodes the time as {"$date": <milliseconds>}.
+func (d DateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]int64{"$date": d.UnixMilli()})
+}
+
+// UnmarshalJSON decodes {"$date": <milliseconds>}, RFC 3339 strings and UNIX timestamps.
+func (d *DateTime) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	switch data[0] {
+	case '{':
+		var wrapped struct {
+			Date int64 `json:"$date"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return fmt.Errorf("invalid datetime %s: %w", data, err)
+		}
+		d.Time = time.UnixMilli(wrapped.Date)
+	case '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return fmt.Errorf("invalid datetime %q: %w", s, err)
+		}
+		d.Time = t
+	default:
+		var seconds float64
+		if err := json.Unmarshal(data, &seconds); err != nil {
+			return fmt.Errorf("invalid datetime %s: %w", data, err)
+		}
+		d.Time = time.UnixMilli(int64(seconds * 1000))
+	}
+	return nil
+}
+
+// Schedule is a cron-like schedule as used by periodic tasks. Each field takes
+// cron syntax, e.g. "*", "*/15", "1-5" or "mon,wed".
+type Schedule struct {
+	Minute string `json:"minute"`
+	Hour   string `json:"hour"`
+	Dom    string `json:"dom"` // Day of month
+	Month  string `json:"month"`
+	Dow    string `json:"dow"`             // Day of week
+	Begin  string `json:"begin,omitempty"` // Start of the daily window, "HH:MM", for tasks that support it
+	End    string `json:"end,omitempty"`   // End of the daily window, "HH:MM", for tasks that support it
+}
+
+// scheduleNames are the names accepted in the month and day of week fields.
+var scheduleNames = map[string][]string{
+	"month": {"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"},
+	"dow":   {"sun", "mon", "tue", "wed", "thu", "fri", "sat"},
+}
+
+// Validate checks the cron syntax and value ranges of each field, so a bad
+// schedule is reported before it is sent to the server.
+func (s Schedule) Validate() error {
+	fields := []struct {
+		name     string
+		value    string
+		min, max int
+	}{
+		{"minute", s.Minute, 0, 59},
+		{"hour", s.Hour, 0, 23},
+		{"dom", s.Dom, 1, 31},
+		{"month", s.Month, 1, 12},
+		{"dow", s.Dow, 0, 7}, // 0 and 7 are both Sunday
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		for _, item := range strings.Split(f.value, ",") {
+			if err := validateCronItem(item, f.name, f.min, f.max); err != nil {
+				return fmt.Errorf("invalid schedule %s %q: %w", f.name, f.value, err)
+			}
+		}
+	}
+	for _, window := range []struct{ name, value string }{{"begin", s.Begin}, {"end", s.End}} {
+		if window.value == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", window.value); err != nil {
+			return fmt.Errorf("invalid schedule %s %q: expected HH:MM", window.name, window.value)
+		}
+	}
+	return nil
+}
+
+// validateCronItem checks a single comma separated item of a cron field:
+// "*", a value or a range, optionally followed by "/step".
+func validateCronItem(item, field string, min, max int) error {
+	rng, step, hasStep := strings.Cut(item, "/")
+	if hasStep {
+		if n, err := strconv.Atoi(step); err != nil || n < 1 {
+			return fmt.Errorf("invalid step %q", step)
+		}
+	}
+	if rng == "*" {
+		return nil
+	}
+	lo, hi, isRange := strings.Cut(rng, "-")
+	low, err := cronValue(lo, field, min, max)
+	if err != nil {
+		return err
+	}
+	if !isRange {
+		return nil
+	}
+	high, err := cronValue(hi, field, min, max)
+	if err != nil {
+		return err
+	}
+	if low > high {
+		return fmt.Errorf("range %q is reversed", rng)
+	}
+	return nil
+}
+
+// cronValue parses a number or, for month and dow, a name and checks its range.
+func cronValue(value, field string, min, max int) (int, error) {
+	for i, name := range scheduleNames[field] {
+		if strings.EqualFold(value, name) {
+			return i + min, nil
+		}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", n, min, max)
+	}
+	return n, nil
+}
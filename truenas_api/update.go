@@ -0,0 +1,120 @@
+package truenas_api
+
+import "context"
+
+// Update statuses returned by Update.CheckAvailable.
+const (
+	UpdateAvailable      = "AVAILABLE"
+	UpdateUnavailable    = "UNAVAILABLE"
+	UpdateRebootRequired = "REBOOT_REQUIRED"
+	UpdateHAUnavailable  = "HA_UNAVAILABLE"
+)
+
+// UpdateTrains lists the update trains, as returned by update.get_trains.
+type UpdateTrains struct {
+	Trains   map[string]UpdateTrain `json:"trains"`
+	Current  string                 `json:"current"`  // Train of the running version
+	Selected string                 `json:"selected"` // Train updates are checked against
+}
+
+// UpdateTrain describes an update train.
+type UpdateTrain struct {
+	Description string `json:"description"`
+}
+
+// UpdateCheck is the result of update.check_available.
+type UpdateCheck struct {
+	Status    string         `json:"status"` // One of the Update* statuses
+	Version   string         `json:"version"`
+	Changes   []UpdateChange `json:"changes"`
+	Notice    string         `json:"notice"`
+	Notes     interface{}    `json:"notes"`
+	Changelog string         `json:"changelog"`
+}
+
+// UpdateChange is a package change of an available update.
+type UpdateChange struct {
+	Operation string `json:"operation"` // e.g. "upgrade"
+	Old       struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"old"`
+	New struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"new"`
+}
+
+// Update provides typed access to the update.* namespace.
+type Update struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewUpdate creates a new Update service.
+func NewUpdate(client *Client) *Update {
+	return &Update{client: client}
+}
+
+// Trains returns the available update trains and the current and selected train.
+func (u *Update) Trains(ctx context.Context) (*UpdateTrains, error) {
+	var trains UpdateTrains
+	if err := u.client.callResult(ctx, "update.get_trains", nil, &trains); err != nil {
+		return nil, err
+	}
+	return &trains, nil
+}
+
+// SetTrain selects the train updates are checked against.
+func (u *Update) SetTrain(ctx context.Context, train string) error {
+	return u.client.callResult(ctx, "update.set_train", []interface{}{train}, nil)
+}
+
+// CheckAvailable checks train, or the selected train if empty, for an update.
+func (u *Update) CheckAvailable(ctx context.Context, train string) (*UpdateCheck, error) {
+	options := map[string]interface{}{}
+	if train != "" {
+		options["train"] = train
+	}
+	var check UpdateCheck
+	if err := u.client.callResult(ctx, "update.check_available", []interface{}{options}, &check); err != nil {
+		return nil, err
+	}
+	return &check, nil
+}
+
+// Download downloads the available update without applying it. The job
+// result is true if an update was downloaded.
+func (u *Update) Download(ctx context.Context, callback JobCallback) (*Job, error) {
+	return u.client.callJob(ctx, "update.download", nil, callback)
+}
+
+// Apply downloads, if needed, and applies the update of train, or of the
+// selected train if empty. With reboot set the system reboots once the update
+// is applied, see ApplyAndWait.
+func (u *Update) Apply(ctx context.Context, train string, reboot bool, callback JobCallback) (*Job, error) {
+	options := map[string]interface{}{"reboot": reboot}
+	if train != "" {
+		options["train"] = train
+	}
+	return u.client.callJob(ctx, "update.update", []interface{}{options}, callback)
+}
+
+// ApplyAndWait applies the update and reboots, then reconnects until the
+// system is back up on the new boot and system.ready returns true. The client
+// must have logged in with Login so it can log in again after the reboot.
+func (u *Update) ApplyAndWait(ctx context.Context, train string, callback JobCallback) error {
+	system := NewSystem(u.client)
+	bootID, err := system.BootID(ctx)
+	if err != nil {
+		return err
+	}
+
+	job, err := u.Apply(ctx, train, true, callback)
+	if err != nil {
+		return err
+	}
+	if err := system.awaitPowerJob(ctx, job, bootID, false); err != nil {
+		return err
+	}
+	return system.waitForBoot(ctx, bootID)
+}
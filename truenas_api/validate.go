@@ -0,0 +1,312 @@
+package truenas_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrSchemasUnavailable is returned, wrapped, by Login on a client with
+// WithValidation when the method schemas could not be loaded. The client is
+// logged in and sends calls without validating them.
+var ErrSchemasUnavailable = errors.New("method schemas unavailable")
+
+// schemaTimeout limits loading the method schemas, which are large.
+const schemaTimeout = 30 * time.Second
+
+// FieldError is a parameter that does not match the schema of a method.
+type FieldError struct {
+	Path    string // e.g. "data.encryption_options.key" or "filters[0]"
+	Message string
+}
+
+// ValidationError is returned by calls with WithValidation when the params
+// do not match the schema of the method. The call is not sent.
+type ValidationError struct {
+	Method string
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Path+": "+fe.Message)
+	}
+	return "invalid params: " + strings.Join(msgs, "; ")
+}
+
+// validator caches the method schemas of core.get_methods for local validation.
+type validator struct {
+	methods map[string]MethodSchema // nil until loaded
+}
+
+// loadSchemas loads the method schemas unless they are already loaded. The
+// call has its own timeout and is made without holding validatorMu, so other
+// calls are not held up.
+func (c *Client) loadSchemas() error {
+	c.validatorMu.Lock()
+	loaded := c.validator.methods != nil
+	c.validatorMu.Unlock()
+	if loaded {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), schemaTimeout)
+	defer cancel()
+	var methods map[string]MethodSchema
+	if err := c.callResult(ctx, "core.get_methods", nil, &methods); err != nil {
+		return fmt.Errorf("%w: %w", ErrSchemasUnavailable, err)
+	}
+	c.validatorMu.Lock()
+	c.validator = validator{methods: methods}
+	c.validatorMu.Unlock()
+	return nil
+}
+
+// validateParams checks params against the schema of method. Methods without
+// a schema, and calls made while no schemas are loaded, are not checked.
+func (c *Client) validateParams(ctx context.Context, method string, params interface{}) error {
+	if strings.HasPrefix(method, "core.") || strings.HasPrefix(method, "auth.") {
+		return nil // Connection and login plumbing, needed to load the schemas
+	}
+
+	c.validatorMu.Lock()
+	schema, ok := c.validator.methods[method]
+	c.validatorMu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	// Validate the params as they are sent, after JSON encoding
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode params: %w", err)
+	}
+	var args []interface{}
+	if err := json.Unmarshal(data, &args); err != nil {
+		return nil // Not a list of positional params, left to the server
+	}
+
+	if errs := schema.validate(args); len(errs) > 0 {
+		return &ValidationError{Method: method, Errors: errs}
+	}
+	return nil
+}
+
+// validate checks positional args against m.Accepts, reporting missing
+// required params and unexpected ones.
+func (m MethodSchema) validate(args []interface{}) []FieldError {
+	if len(args) > len(m.Accepts) {
+		return []FieldError{{Path: "params", Message: fmt.Sprintf("got %d parameters, method accepts %d", len(args), len(m.Accepts))}}
+	}
+	defs := make(map[string]*Schema)
+	for _, s := range m.Accepts {
+		collectDefs(s, defs)
+	}
+
+	var errs []FieldError
+	for i, param := range m.Accepts {
+		path := param.ParamName()
+		if path == "" {
+			path = fmt.Sprintf("params[%d]", i)
+		}
+		if i >= len(args) {
+			if param.RequiredParam() {
+				errs = append(errs, FieldError{Path: path, Message: "required"})
+			}
+			continue
+		}
+		errs = append(errs, param.validate(args[i], path, defs)...)
+	}
+	return errs
+}
+
+// collectDefs adds the $defs of s and of the definitions themselves to defs.
+func collectDefs(s *Schema, defs map[string]*Schema) {
+	if s == nil {
+		return
+	}
+	for name, def := range s.Defs {
+		defs[name] = def
+		collectDefs(def, defs)
+	}
+}
+
+// validate checks v, a decoded JSON value, against s. oneOf is checked like
+// anyOf, and formats are not checked.
+func (s *Schema) validate(v interface{}, path string, defs map[string]*Schema) []FieldError {
+	if s == nil {
+		return nil
+	}
+	fail := func(format string, args ...interface{}) []FieldError {
+		return []FieldError{{Path: path, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	if s.Never {
+		return fail("not allowed")
+	}
+	if s.Ref != "" {
+		if def, ok := defs[s.RefName()]; ok {
+			return def.validate(v, path, defs)
+		}
+		return nil
+	}
+
+	var errs []FieldError
+	for _, sub := range s.AllOf {
+		errs = append(errs, sub.validate(v, path, defs)...)
+	}
+	for _, variants := range [][]*Schema{s.AnyOf, s.OneOf} {
+		if len(variants) > 0 {
+			errs = append(errs, validateVariants(variants, v, path, defs)...)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if len(s.Type) > 0 && !s.Type.Has(jsonType(v)) && !(jsonType(v) == "integer" && s.Type.Has("number")) {
+		return fail("expected %s, got %s", strings.Join(s.Type, " or "), jsonType(v))
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			if reflect.DeepEqual(v, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail("%s is not one of %s", formatValue(v), formatValue(s.Enum))
+		}
+	}
+	if len(s.Const) > 0 {
+		var want interface{}
+		if err := json.Unmarshal(s.Const, &want); err == nil && !reflect.DeepEqual(v, want) {
+			return fail("must be %s", s.Const)
+		}
+	}
+
+	switch v := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			return fail("must be at least %d characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return fail("must be at most %d characters long", *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			// Python patterns that Go does not support are left to the server
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(v) {
+				return fail("does not match %s", s.Pattern)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			return fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			return fail("must be at most %v", *s.Maximum)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return fail("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return fail("must have at most %d items", *s.MaxItems)
+		}
+		for i, item := range v {
+			itemSchema := s.Items
+			if i < len(s.PrefixItems) {
+				itemSchema = s.PrefixItems[i]
+			}
+			errs = append(errs, itemSchema.validate(item, fmt.Sprintf("%s[%d]", path, i), defs)...)
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, FieldError{Path: path + "." + name, Message: "required"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names) // Report errors in a stable order
+		for _, name := range names {
+			value := v[name]
+			if propSchema, ok := s.Properties[name]; ok {
+				errs = append(errs, propSchema.validate(value, path+"."+name, defs)...)
+			} else if s.AdditionalProperties != nil {
+				if s.AdditionalProperties.Never {
+					errs = append(errs, FieldError{Path: path + "." + name, Message: "unknown field"})
+				} else {
+					errs = append(errs, s.AdditionalProperties.validate(value, path+"."+name, defs)...)
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// validateVariants checks that v matches one of variants. If it matches none,
+// the errors of the only non-null variant are returned, as they are more
+// specific than a general mismatch.
+func validateVariants(variants []*Schema, v interface{}, path string, defs map[string]*Schema) []FieldError {
+	var nonNull []*Schema
+	var nonNullErrs []FieldError
+	for _, variant := range variants {
+		errs := variant.validate(v, path, defs)
+		if len(errs) == 0 {
+			return nil
+		}
+		if len(variant.Type) != 1 || variant.Type[0] != "null" {
+			nonNull = append(nonNull, variant)
+			nonNullErrs = errs
+		}
+	}
+	if len(nonNull) == 1 {
+		return nonNullErrs
+	}
+	return []FieldError{{Path: path, Message: "does not match any of the allowed schemas"}}
+}
+
+// jsonType returns the JSON schema type of a decoded JSON value.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// formatValue returns v as JSON, for error messages.
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
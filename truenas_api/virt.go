@@ -0,0 +1,210 @@
+package truenas_api
+
+import (
+	"context"
+	"fmt"
+)
+
+// Virt instance types.
+const (
+	VirtContainer = "CONTAINER"
+	VirtVM        = "VM"
+)
+
+// VirtInstance is a container or VM as returned by virt.instance.query.
+type VirtInstance struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Type         string            `json:"type"`   // VirtContainer or VirtVM
+	Status       string            `json:"status"` // "RUNNING", "STOPPED", "UNKNOWN", ...
+	CPU          *string           `json:"cpu"`    // CPU limit, e.g. "2" or "0-3"
+	Memory       *int64            `json:"memory"` // Bytes
+	Autostart    bool              `json:"autostart"`
+	Environment  map[string]string `json:"environment"`
+	Aliases      []InterfaceAlias  `json:"aliases"`
+	Image        VirtImage         `json:"image"`
+	StoragePool  string            `json:"storage_pool"`
+	VNCEnabled   bool              `json:"vnc_enabled"`
+	VNCPort      *int              `json:"vnc_port"`
+	SecureBoot   bool              `json:"secure_boot"`
+	RootDiskSize *int64            `json:"root_disk_size"` // GiB, VMs only
+}
+
+// VirtImage describes the image an instance was created from.
+type VirtImage struct {
+	Architecture string `json:"architecture"`
+	Description  string `json:"description"`
+	OS           string `json:"os"`
+	Release      string `json:"release"`
+	Serial       string `json:"serial"`
+	Type         string `json:"type"`
+	Variant      string `json:"variant"`
+}
+
+// VirtDevice is a device of a virt instance. Which fields apply depends on DevType.
+type VirtDevice struct {
+	DevType      string `json:"dev_type"` // "DISK", "NIC", "PROXY", "USB", "TPM", "GPU" or "CDROM"
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Readonly     bool   `json:"readonly"`
+	Source       string `json:"source,omitempty"`      // DISK, CDROM: host path or zvol
+	Destination  string `json:"destination,omitempty"` // DISK: mount point in containers
+	BootPriority *int   `json:"boot_priority,omitempty"`
+	IOBus        string `json:"io_bus,omitempty"`   // DISK: "NVME", "VIRTIO-BLK" or "VIRTIO-SCSI"
+	Network      string `json:"network,omitempty"`  // NIC: managed network
+	NICType      string `json:"nic_type,omitempty"` // NIC: "BRIDGED" or "MACVLAN"
+	Parent       string `json:"parent,omitempty"`   // NIC: host interface
+	SourceProto  string `json:"source_proto,omitempty"`
+	SourcePort   int    `json:"source_port,omitempty"`
+	DestProto    string `json:"dest_proto,omitempty"`
+	DestPort     int    `json:"dest_port,omitempty"`
+	ProductID    string `json:"product_id,omitempty"` // USB
+	VendorID     string `json:"vendor_id,omitempty"`  // USB
+	PCI          string `json:"pci,omitempty"`        // GPU
+	GPUType      string `json:"gpu_type,omitempty"`   // GPU: "PHYSICAL"
+}
+
+// VirtInstanceCreateRequest holds the fields of virt.instance.create.
+type VirtInstanceCreateRequest struct {
+	Name         string            `json:"name"`
+	SourceType   string            `json:"source_type,omitempty"` // "IMAGE" by default
+	Image        string            `json:"image"`                 // e.g. "debian/bookworm", see ImageChoices
+	Remote       string            `json:"remote,omitempty"`      // "LINUX_CONTAINERS" by default
+	InstanceType string            `json:"instance_type,omitempty"`
+	Environment  map[string]string `json:"environment,omitempty"`
+	Autostart    bool              `json:"autostart"`
+	CPU          string            `json:"cpu,omitempty"`
+	Memory       int64             `json:"memory,omitempty"` // Bytes
+	Devices      []VirtDevice      `json:"devices,omitempty"`
+	RootDiskSize int64             `json:"root_disk_size,omitempty"` // GiB, VMs only
+	StoragePool  string            `json:"storage_pool,omitempty"`
+}
+
+// VirtInstanceUpdateRequest holds the writable fields of a virt instance.
+type VirtInstanceUpdateRequest struct {
+	Environment map[string]string `json:"environment,omitempty"`
+	Autostart   bool              `json:"autostart"`
+	CPU         *string           `json:"cpu"`
+	Memory      *int64            `json:"memory"`
+}
+
+// Request returns the writable fields of the instance, for use with VirtInstances.Update.
+func (v VirtInstance) Request() VirtInstanceUpdateRequest {
+	return VirtInstanceUpdateRequest{
+		Environment: v.Environment,
+		Autostart:   v.Autostart,
+		CPU:         v.CPU,
+		Memory:      v.Memory,
+	}
+}
+
+// VirtImageChoice is an image offered by a remote image server.
+type VirtImageChoice struct {
+	Label         string   `json:"label"`
+	OS            string   `json:"os"`
+	Release       string   `json:"release"`
+	Archs         []string `json:"archs"`
+	Variant       string   `json:"variant"`
+	InstanceTypes []string `json:"instance_types"`
+	SecureBoot    *bool    `json:"secureboot"`
+}
+
+// VirtInstances provides typed access to the virt.instance.* namespace.
+type VirtInstances struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewVirtInstances creates a new VirtInstances service.
+func NewVirtInstances(client *Client) *VirtInstances {
+	return &VirtInstances{client: client}
+}
+
+// Query returns the instances matching filters.
+func (v *VirtInstances) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]VirtInstance, error) {
+	var instances []VirtInstance
+	err := v.client.callResult(ctx, "virt.instance.query", queryParams(filters, opts), &instances)
+	return instances, err
+}
+
+// Get returns an instance by ID.
+func (v *VirtInstances) Get(ctx context.Context, id string) (*VirtInstance, error) {
+	var instance VirtInstance
+	if err := v.client.callResult(ctx, "virt.instance.get_instance", []interface{}{id}, &instance); err != nil {
+		return nil, err
+	}
+	return &instance, nil
+}
+
+// ImageChoices returns the images available on remote, e.g. "LINUX_CONTAINERS", keyed by image name.
+func (v *VirtInstances) ImageChoices(ctx context.Context, remote string) (map[string]VirtImageChoice, error) {
+	var choices map[string]VirtImageChoice
+	err := v.client.callResult(ctx, "virt.instance.image_choices", []interface{}{map[string]interface{}{"remote": remote}}, &choices)
+	return choices, err
+}
+
+// Create creates an instance from an image. The job result is the new instance.
+func (v *VirtInstances) Create(ctx context.Context, req VirtInstanceCreateRequest, callback JobCallback) (*Job, error) {
+	return v.client.callJob(ctx, "virt.instance.create", []interface{}{req}, callback)
+}
+
+// Update replaces the writable fields of an instance.
+func (v *VirtInstances) Update(ctx context.Context, id string, req VirtInstanceUpdateRequest, callback JobCallback) (*Job, error) {
+	return v.client.callJob(ctx, "virt.instance.update", []interface{}{id, req}, callback)
+}
+
+// Delete deletes an instance.
+func (v *VirtInstances) Delete(ctx context.Context, id string, callback JobCallback) (*Job, error) {
+	return v.client.callJob(ctx, "virt.instance.delete", []interface{}{id}, callback)
+}
+
+// Start starts an instance.
+func (v *VirtInstances) Start(ctx context.Context, id string, callback JobCallback) (*Job, error) {
+	return v.client.callJob(ctx, "virt.instance.start", []interface{}{id}, callback)
+}
+
+// Stop stops an instance, waiting up to timeoutSeconds for a clean shutdown,
+// or killing it right away with force set.
+func (v *VirtInstances) Stop(ctx context.Context, id string, timeoutSeconds int, force bool, callback JobCallback) (*Job, error) {
+	options := map[string]interface{}{"timeout": timeoutSeconds, "force": force}
+	return v.client.callJob(ctx, "virt.instance.stop", []interface{}{id, options}, callback)
+}
+
+// Restart restarts an instance, see Stop.
+func (v *VirtInstances) Restart(ctx context.Context, id string, timeoutSeconds int, force bool, callback JobCallback) (*Job, error) {
+	options := map[string]interface{}{"timeout": timeoutSeconds, "force": force}
+	return v.client.callJob(ctx, "virt.instance.restart", []interface{}{id, options}, callback)
+}
+
+// Devices returns the devices of an instance.
+func (v *VirtInstances) Devices(ctx context.Context, id string) ([]VirtDevice, error) {
+	var devices []VirtDevice
+	err := v.client.callResult(ctx, "virt.instance.device_list", []interface{}{id}, &devices)
+	return devices, err
+}
+
+// AddDevice adds a device to an instance.
+func (v *VirtInstances) AddDevice(ctx context.Context, id string, device VirtDevice) error {
+	return v.deviceCall(ctx, "virt.instance.device_add", id, device)
+}
+
+// UpdateDevice replaces a device of an instance, matched by name.
+func (v *VirtInstances) UpdateDevice(ctx context.Context, id string, device VirtDevice) error {
+	return v.deviceCall(ctx, "virt.instance.device_update", id, device)
+}
+
+// DeleteDevice removes a device from an instance by name.
+func (v *VirtInstances) DeleteDevice(ctx context.Context, id, name string) error {
+	return v.deviceCall(ctx, "virt.instance.device_delete", id, name)
+}
+
+// deviceCall calls a virt.instance.device_* method, which reports success as a boolean.
+func (v *VirtInstances) deviceCall(ctx context.Context, method, id string, arg interface{}) error {
+	var ok bool
+	if err := v.client.callResult(ctx, method, []interface{}{id, arg}, &ok); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%s: failed for instance %s", method, id)
+	}
+	return nil
+}
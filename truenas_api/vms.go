@@ -0,0 +1,298 @@
+package truenas_api
+
+import (
+	"context"
+	"fmt"
+)
+
+// VM states reported in VMStatus.
+const (
+	VMRunning   = "RUNNING"
+	VMStopped   = "STOPPED"
+	VMSuspended = "SUSPENDED"
+)
+
+// VM device types.
+const (
+	VMDeviceDisk    = "DISK"
+	VMDeviceCDROM   = "CDROM"
+	VMDeviceNIC     = "NIC"
+	VMDevicePCI     = "PCI"
+	VMDeviceRaw     = "RAW"
+	VMDeviceDisplay = "DISPLAY"
+	VMDeviceUSB     = "USB"
+)
+
+// VM is a virtual machine as returned by vm.query.
+type VM struct {
+	ID                   int        `json:"id"`
+	Name                 string     `json:"name"`
+	Description          string     `json:"description"`
+	VCPUs                int        `json:"vcpus"`
+	Cores                int        `json:"cores"`
+	Threads              int        `json:"threads"`
+	Memory               int64      `json:"memory"`     // MiB
+	MinMemory            *int64     `json:"min_memory"` // MiB, enables ballooning if set
+	Autostart            bool       `json:"autostart"`
+	Time                 string     `json:"time"`       // "LOCAL" or "UTC"
+	Bootloader           string     `json:"bootloader"` // "UEFI" or "UEFI_CSM"
+	BootloaderOVMF       string     `json:"bootloader_ovmf"`
+	CPUMode              string     `json:"cpu_mode"` // "CUSTOM", "HOST-MODEL" or "HOST-PASSTHROUGH"
+	CPUModel             *string    `json:"cpu_model"`
+	CPUSet               *string    `json:"cpuset"`
+	NodeSet              *string    `json:"nodeset"`
+	PinVCPUs             bool       `json:"pin_vcpus"`
+	HideFromMSR          bool       `json:"hide_from_msr"`
+	HypervEnlightenments bool       `json:"hyperv_enlightenments"`
+	ShutdownTimeout      int        `json:"shutdown_timeout"` // Seconds
+	EnsureDisplayDevice  bool       `json:"ensure_display_device"`
+	SuspendOnSnapshot    bool       `json:"suspend_on_snapshot"`
+	EnableSecureBoot     bool       `json:"enable_secure_boot"`
+	TPM                  bool       `json:"trusted_platform_module"`
+	ArchType             *string    `json:"arch_type"`
+	MachineType          *string    `json:"machine_type"`
+	UUID                 string     `json:"uuid"`
+	CommandLineArgs      string     `json:"command_line_args"`
+	Devices              []VMDevice `json:"devices"`
+	DisplayAvailable     bool       `json:"display_available"`
+	Status               VMStatus   `json:"status"`
+}
+
+// VMStatus is the run state of a VM.
+type VMStatus struct {
+	State       string `json:"state"` // One of the VM* states
+	PID         *int   `json:"pid"`
+	DomainState string `json:"domain_state"`
+}
+
+// VMDevice is a device attached to a VM.
+type VMDevice struct {
+	ID         int                    `json:"id"`
+	DType      string                 `json:"dtype"` // One of the VMDevice* types
+	Attributes map[string]interface{} `json:"attributes"`
+	Order      *int                   `json:"order"`
+	VM         int                    `json:"vm"`
+}
+
+// VMDeviceRequest holds the writable fields of a VM device. VM is set
+// automatically when the device is created along with the VM.
+type VMDeviceRequest struct {
+	DType      string                 `json:"dtype"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Order      *int                   `json:"order,omitempty"`
+	VM         int                    `json:"vm,omitempty"`
+}
+
+// NewVMDiskDevice returns a disk device backed by a zvol, e.g. "/dev/zvol/tank/vm-disk".
+func NewVMDiskDevice(path string) VMDeviceRequest {
+	return VMDeviceRequest{DType: VMDeviceDisk, Attributes: map[string]interface{}{"path": path, "type": "VIRTIO"}}
+}
+
+// NewVMCDROMDevice returns a CD-ROM device backed by an ISO image.
+func NewVMCDROMDevice(path string) VMDeviceRequest {
+	return VMDeviceRequest{DType: VMDeviceCDROM, Attributes: map[string]interface{}{"path": path}}
+}
+
+// NewVMNICDevice returns a VirtIO network device attached to a host interface, e.g. "br0".
+// An empty mac generates a random MAC address.
+func NewVMNICDevice(attach, mac string) VMDeviceRequest {
+	attributes := map[string]interface{}{"type": "VIRTIO", "nic_attach": attach}
+	if mac != "" {
+		attributes["mac"] = mac
+	}
+	return VMDeviceRequest{DType: VMDeviceNIC, Attributes: attributes}
+}
+
+// NewVMDisplayDevice returns a SPICE display with a web client, listening on bind.
+func NewVMDisplayDevice(bind, password string) VMDeviceRequest {
+	return VMDeviceRequest{DType: VMDeviceDisplay, Attributes: map[string]interface{}{
+		"type":     "SPICE",
+		"bind":     bind,
+		"password": password,
+		"web":      true,
+	}}
+}
+
+// VMRequest holds the writable fields of a VM. Devices are only used on create.
+type VMRequest struct {
+	Name                 string            `json:"name"`
+	Description          string            `json:"description"`
+	VCPUs                int               `json:"vcpus"`
+	Cores                int               `json:"cores"`
+	Threads              int               `json:"threads"`
+	Memory               int64             `json:"memory"`
+	MinMemory            *int64            `json:"min_memory"`
+	Autostart            bool              `json:"autostart"`
+	Time                 string            `json:"time,omitempty"`
+	Bootloader           string            `json:"bootloader,omitempty"`
+	CPUMode              string            `json:"cpu_mode,omitempty"`
+	CPUModel             *string           `json:"cpu_model,omitempty"`
+	ShutdownTimeout      int               `json:"shutdown_timeout,omitempty"`
+	HypervEnlightenments bool              `json:"hyperv_enlightenments"`
+	EnableSecureBoot     bool              `json:"enable_secure_boot"`
+	TPM                  bool              `json:"trusted_platform_module"`
+	Devices              []VMDeviceRequest `json:"devices,omitempty"`
+}
+
+// Request returns the writable fields of the VM, for use with VMs.Update.
+func (v VM) Request() VMRequest {
+	return VMRequest{
+		Name:                 v.Name,
+		Description:          v.Description,
+		VCPUs:                v.VCPUs,
+		Cores:                v.Cores,
+		Threads:              v.Threads,
+		Memory:               v.Memory,
+		MinMemory:            v.MinMemory,
+		Autostart:            v.Autostart,
+		Time:                 v.Time,
+		Bootloader:           v.Bootloader,
+		CPUMode:              v.CPUMode,
+		CPUModel:             v.CPUModel,
+		ShutdownTimeout:      v.ShutdownTimeout,
+		HypervEnlightenments: v.HypervEnlightenments,
+		EnableSecureBoot:     v.EnableSecureBoot,
+		TPM:                  v.TPM,
+	}
+}
+
+// VMDisplayURI is a web client URI of a VM display device.
+type VMDisplayURI struct {
+	URI   string  `json:"uri"`
+	Error *string `json:"error"` // Set if no URI is available
+}
+
+// VMs provides typed access to the vm.* namespace.
+type VMs struct {
+	client *Client // Reference to the WebSocket client
+}
+
+// NewVMs creates a new VMs service.
+func NewVMs(client *Client) *VMs {
+	return &VMs{client: client}
+}
+
+// Query returns the VMs matching filters.
+func (v *VMs) Query(ctx context.Context, filters []Filter, opts *QueryOptions) ([]VM, error) {
+	var vms []VM
+	err := v.client.callResult(ctx, "vm.query", queryParams(filters, opts), &vms)
+	return vms, err
+}
+
+// Get returns a VM by ID.
+func (v *VMs) Get(ctx context.Context, id int) (*VM, error) {
+	var vm VM
+	if err := v.client.callResult(ctx, "vm.get_instance", []interface{}{id}, &vm); err != nil {
+		return nil, err
+	}
+	return &vm, nil
+}
+
+// Create creates a VM along with its devices.
+func (v *VMs) Create(ctx context.Context, req VMRequest) (*VM, error) {
+	var vm VM
+	if err := v.client.callResult(ctx, "vm.create", []interface{}{req}, &vm); err != nil {
+		return nil, err
+	}
+	return &vm, nil
+}
+
+// Update replaces the writable fields of a VM. Devices are managed with AddDevice and DeleteDevice.
+func (v *VMs) Update(ctx context.Context, id int, req VMRequest) (*VM, error) {
+	req.Devices = nil
+	var vm VM
+	if err := v.client.callResult(ctx, "vm.update", []interface{}{id, req}, &vm); err != nil {
+		return nil, err
+	}
+	return &vm, nil
+}
+
+// Delete deletes a VM. With deleteZvols set the zvols of its disk devices are deleted too.
+func (v *VMs) Delete(ctx context.Context, id int, deleteZvols, force bool) error {
+	options := map[string]interface{}{"zvols": deleteZvols, "force": force}
+	return v.client.callResult(ctx, "vm.delete", []interface{}{id, options}, nil)
+}
+
+// Start starts a VM. With overcommit set it starts even if there isn't enough free memory.
+func (v *VMs) Start(ctx context.Context, id int, overcommit bool) error {
+	return v.client.callResult(ctx, "vm.start", []interface{}{id, map[string]interface{}{"overcommit": overcommit}}, nil)
+}
+
+// Stop shuts down a VM via ACPI. With forceAfterTimeout set it is powered off
+// if it didn't shut down within its shutdown timeout.
+func (v *VMs) Stop(ctx context.Context, id int, forceAfterTimeout bool, callback JobCallback) (*Job, error) {
+	options := map[string]interface{}{"force": false, "force_after_timeout": forceAfterTimeout}
+	return v.client.callJob(ctx, "vm.stop", []interface{}{id, options}, callback)
+}
+
+// Restart shuts down and starts a VM.
+func (v *VMs) Restart(ctx context.Context, id int, callback JobCallback) (*Job, error) {
+	return v.client.callJob(ctx, "vm.restart", []interface{}{id}, callback)
+}
+
+// PowerOff powers off a VM immediately.
+func (v *VMs) PowerOff(ctx context.Context, id int) error {
+	return v.client.callResult(ctx, "vm.poweroff", []interface{}{id}, nil)
+}
+
+// Suspend suspends a running VM.
+func (v *VMs) Suspend(ctx context.Context, id int) error {
+	return v.client.callResult(ctx, "vm.suspend", []interface{}{id}, nil)
+}
+
+// Resume resumes a suspended VM.
+func (v *VMs) Resume(ctx context.Context, id int) error {
+	return v.client.callResult(ctx, "vm.resume", []interface{}{id}, nil)
+}
+
+// Status returns the run state of a VM.
+func (v *VMs) Status(ctx context.Context, id int) (*VMStatus, error) {
+	var status VMStatus
+	if err := v.client.callResult(ctx, "vm.status", []interface{}{id}, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Console returns the serial console device of a running VM, for use with virsh console.
+func (v *VMs) Console(ctx context.Context, id int) (string, error) {
+	var console string
+	err := v.client.callResult(ctx, "vm.get_console", []interface{}{id}, &console)
+	return console, err
+}
+
+// DisplayDevices returns the display devices of a VM.
+func (v *VMs) DisplayDevices(ctx context.Context, id int) ([]VMDevice, error) {
+	var devices []VMDevice
+	err := v.client.callResult(ctx, "vm.get_display_devices", []interface{}{id}, &devices)
+	return devices, err
+}
+
+// DisplayWebURI returns the web client URI of the display of a VM, reached
+// via host, the address of the NAS as seen by the user.
+func (v *VMs) DisplayWebURI(ctx context.Context, id int, host string) (*VMDisplayURI, error) {
+	var uri VMDisplayURI
+	options := map[string]interface{}{"protocol": "HTTP"}
+	if err := v.client.callResult(ctx, "vm.get_display_web_uri", []interface{}{id, host, options}, &uri); err != nil {
+		return nil, err
+	}
+	if uri.Error != nil {
+		return nil, fmt.Errorf("vm %d has no display URI: %s", id, *uri.Error)
+	}
+	return &uri, nil
+}
+
+// AddDevice attaches a device to the VM with vmID.
+func (v *VMs) AddDevice(ctx context.Context, vmID int, req VMDeviceRequest) (*VMDevice, error) {
+	req.VM = vmID
+	var device VMDevice
+	if err := v.client.callResult(ctx, "vm.device.create", []interface{}{req}, &device); err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// DeleteDevice removes a device from its VM.
+func (v *VMs) DeleteDevice(ctx context.Context, id int) error {
+	return v.client.callResult(ctx, "vm.device.delete", []interface{}{id}, nil)
+}
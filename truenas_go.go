@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"truenas_api/truenas_api" // Replace with the correct package path
@@ -31,7 +35,396 @@ func printPrettyJSON(data interface{}) {
 	fmt.Println(string(prettyResponse))
 }
 
+// commands maps subcommand names to their implementation.
+var commands = map[string]func(args []string){
+	"service": serviceCommand,
+	"cert":    certCommand,
+	"audit":   auditCommand,
+	"config":  configCommand,
+	"debug":   debugCommand,
+}
+
+// connFlags holds the connection and login flags shared by the subcommands.
+type connFlags struct {
+	serverURL *string
+	verifySSL *bool
+	user      *string
+	pass      *string
+	apiKey    *string
+	timeout   *int
+	validate  *bool
+}
+
+// addConnFlags defines the connection and login flags on fs.
+func addConnFlags(fs *flag.FlagSet) *connFlags {
+	return &connFlags{
+		serverURL: fs.String("uri", "", "WebSocket server URI (e.g., ws://localhost:6000/websocket)"),
+		verifySSL: fs.Bool("verifyssl", true, "Verify SSL certificates for wss:// connections"),
+		user:      fs.String("U", "", "Username for login"),
+		pass:      fs.String("P", "", "Password for login"),
+		apiKey:    fs.String("api-key", "", "API key for login"),
+		timeout:   fs.Int("timeout", 60, "Timeout in seconds for the command"),
+		validate:  fs.Bool("validate", false, "Validate params against the method schemas before sending"),
+	}
+}
+
+// connect creates a client and logs in if credentials or an API key are provided.
+func (f *connFlags) connect() *truenas_api.Client {
+	if *f.serverURL == "" {
+		log.Fatal("--uri must be provided")
+	}
+	var opts []truenas_api.ClientOption
+	if *f.validate {
+		opts = append(opts, truenas_api.WithValidation())
+	}
+	client, err := truenas_api.NewClient(*f.serverURL, *f.verifySSL, opts...)
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+	if *f.apiKey != "" || (*f.user != "" && *f.pass != "") {
+		if err := client.Login(*f.user, *f.pass, *f.apiKey); errors.Is(err, truenas_api.ErrSchemasUnavailable) {
+			log.Printf("Warning: %v, sending calls unvalidated", err)
+		} else if err != nil {
+			client.Close()
+			log.Fatalf("Login failed: %v", err)
+		}
+	}
+	return client
+}
+
+// context returns a context that ends after the --timeout.
+func (f *connFlags) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(*f.timeout)*time.Second)
+}
+
+// parseFlags parses args with flags and positional arguments in any order and
+// returns the positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args) // Exits on error, see flag.ExitOnError
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// serviceAliases maps common names to TrueNAS service names.
+var serviceAliases = map[string]string{
+	"smb": "cifs",
+}
+
+// serviceCommand implements "truenas_go service status|start|stop|restart [name]".
+func serviceCommand(args []string) {
+	fs := flag.NewFlagSet("service", flag.ExitOnError)
+	conn := addConnFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: truenas_go service status [name] | start|stop|restart <name> [flags]")
+		fs.PrintDefaults()
+	}
+	positional := parseFlags(fs, args)
+	if len(positional) == 0 || (positional[0] != "status" && len(positional) != 2) || len(positional) > 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	action := positional[0]
+	var name string
+	if len(positional) == 2 {
+		name = strings.ToLower(positional[1])
+		if alias, ok := serviceAliases[name]; ok {
+			name = alias
+		}
+	}
+
+	client := conn.connect()
+	defer client.Close()
+	ctx, cancel := conn.context()
+	defer cancel()
+	services := truenas_api.NewServices(client)
+
+	var err error
+	var want string
+	switch action {
+	case "status":
+		var filters []truenas_api.Filter
+		if name != "" {
+			filters = []truenas_api.Filter{{"service", "=", name}}
+		}
+		list, err := services.Query(ctx, filters, &truenas_api.QueryOptions{OrderBy: []string{"service"}})
+		if err != nil {
+			log.Fatalf("Failed to query services: %v", err)
+		}
+		if name != "" && len(list) == 0 {
+			log.Fatalf("Service %q not found", name)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "SERVICE\tSTATE\tSTART ON BOOT")
+		for _, service := range list {
+			fmt.Fprintf(w, "%s\t%s\t%t\n", service.Service, service.State, service.Enable)
+		}
+		w.Flush()
+		return
+	case "start":
+		err, want = services.Start(ctx, name), truenas_api.ServiceRunning
+	case "stop":
+		err, want = services.Stop(ctx, name), truenas_api.ServiceStopped
+	case "restart":
+		err, want = services.Restart(ctx, name), truenas_api.ServiceRunning
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("Failed to %s %s: %v", action, name, err)
+	}
+	service, err := services.WaitForState(ctx, name, want, time.Second)
+	if err != nil {
+		log.Fatalf("Failed to %s %s: %v", action, name, err)
+	}
+	fmt.Printf("%s is %s\n", service.Service, service.State)
+}
+
+// certCommand implements "truenas_go cert expiring --days N".
+func certCommand(args []string) {
+	fs := flag.NewFlagSet("cert", flag.ExitOnError)
+	conn := addConnFlags(fs)
+	days := fs.Int("days", 30, "Report certificates expiring within this many days")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: truenas_go cert expiring [--days N] [flags]")
+		fs.PrintDefaults()
+	}
+	positional := parseFlags(fs, args)
+	if len(positional) != 1 || positional[0] != "expiring" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	client := conn.connect()
+	defer client.Close()
+	ctx, cancel := conn.context()
+	defer cancel()
+
+	within := time.Duration(*days) * 24 * time.Hour
+	certs, err := truenas_api.NewCertificates(client).Expiring(ctx, within)
+	if err != nil {
+		log.Fatalf("Failed to query certificates: %v", err)
+	}
+	cas, err := truenas_api.NewCertificateAuthorities(client).Expiring(ctx, within)
+	if err != nil {
+		log.Fatalf("Failed to query certificate authorities: %v", err)
+	}
+
+	if len(certs) == 0 && len(cas) == 0 {
+		fmt.Printf("No certificates expire within %d days\n", *days)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tID\tNAME\tCOMMON NAME\tEXPIRES\tDAYS LEFT")
+	for _, cert := range append(certs, cas...) {
+		expiresAt, _ := cert.ExpiresAt() // Only parsable certificates are returned
+		left := int(time.Until(expiresAt).Hours() / 24)
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%d\n", cert.CertType, cert.ID, cert.Name, cert.Common, expiresAt.Format("2006-01-02"), left)
+	}
+	w.Flush()
+}
+
+// auditCommand implements "truenas_go audit --since 24h --service SMB".
+func auditCommand(args []string) {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	conn := addConnFlags(fs)
+	since := fs.Duration("since", 24*time.Hour, "Show records of this period up to now")
+	service := fs.String("service", "", "Comma separated services to show: MIDDLEWARE, SMB, SUDO (default all)")
+	user := fs.String("user", "", "Only show records of this user")
+	event := fs.String("event", "", "Only show records of this event, e.g. AUTHENTICATION")
+	export := fs.String("export", "", "Export in this format instead of listing: CSV, JSON or YAML")
+	out := fs.String("out", "", "File to export to (default stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: truenas_go audit [--since 24h] [--service SMB] [--user name] [--export CSV --out file] [flags]")
+		fs.PrintDefaults()
+	}
+	if len(parseFlags(fs, args)) != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	query := truenas_api.AuditQuery{
+		Since:    time.Now().Add(-*since),
+		Username: *user,
+		Event:    *event,
+	}
+	if *service != "" {
+		query.Services = strings.Split(strings.ToUpper(*service), ",")
+	}
+
+	client := conn.connect()
+	defer client.Close()
+	ctx, cancel := conn.context()
+	defer cancel()
+	audit := truenas_api.NewAudit(client)
+
+	if *export != "" {
+		w := os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				log.Fatalf("Failed to create %s: %v", *out, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := audit.Export(ctx, query, strings.ToUpper(*export), w); err != nil {
+			log.Fatalf("Failed to export audit records: %v", err)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tSERVICE\tUSER\tADDRESS\tEVENT\tSUCCESS")
+	it := audit.Iterate(ctx, query, nil, 500)
+	for it.Next() {
+		entry := it.Value()
+		timestamp := time.Unix(entry.MessageTimestamp, 0).Format(time.RFC3339)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\n", timestamp, entry.Service, entry.Username, entry.Address, entry.Event, entry.Success)
+	}
+	w.Flush()
+	if err := it.Err(); err != nil {
+		log.Fatalf("Failed to query audit records: %v", err)
+	}
+}
+
+// configCommand implements "truenas_go config backup|restore".
+func configCommand(args []string) {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	conn := addConnFlags(fs)
+	out := fs.String("out", "", "File to write the backup to (default stdout)")
+	secretSeed := fs.Bool("secretseed", false, "Include the secret seed in the backup, needed to restore stored passwords and keys")
+	yes := fs.Bool("yes", false, "Confirm the restore, which replaces the configuration and reboots the system")
+	wait := fs.Bool("wait", false, "After a restore, wait until the system is back up")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: truenas_go config backup [--secretseed] [--out file] | restore <file> --yes [--wait] [flags]")
+		fs.PrintDefaults()
+	}
+	positional := parseFlags(fs, args)
+	if len(positional) == 0 || (positional[0] == "backup" && len(positional) != 1) || (positional[0] == "restore" && len(positional) != 2) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	switch positional[0] {
+	case "backup":
+		client := conn.connect()
+		defer client.Close()
+		ctx, cancel := conn.context()
+		defer cancel()
+
+		w := os.Stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				log.Fatalf("Failed to create %s: %v", *out, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := truenas_api.NewSystem(client).SaveConfig(ctx, w, *secretSeed); err != nil {
+			if *out != "" {
+				os.Remove(*out)
+			}
+			log.Fatalf("Failed to back up the configuration: %v", err)
+		}
+	case "restore":
+		if !*yes {
+			log.Fatal("Restoring replaces the configuration and reboots the system, pass --yes to confirm")
+		}
+		f, err := os.Open(positional[1])
+		if err != nil {
+			log.Fatalf("Failed to open %s: %v", positional[1], err)
+		}
+		defer f.Close()
+
+		client := conn.connect()
+		defer client.Close()
+		ctx, cancel := conn.context()
+		defer cancel()
+		system := truenas_api.NewSystem(client)
+
+		if *wait {
+			if err := system.UploadConfigAndWait(ctx, f); err != nil {
+				log.Fatalf("Failed to restore the configuration: %v", err)
+			}
+			fmt.Println("Configuration restored, system is ready")
+			return
+		}
+		if err := system.UploadConfig(ctx, f); err != nil {
+			log.Fatalf("Failed to restore the configuration: %v", err)
+		}
+		fmt.Println("Configuration uploaded, the system is rebooting")
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+// debugCommand implements "truenas_go debug --out nas1-debug.tgz".
+func debugCommand(args []string) {
+	fs := flag.NewFlagSet("debug", flag.ExitOnError)
+	conn := addConnFlags(fs)
+	*conn.timeout = 900 // Generating the archive takes several minutes
+	fs.Lookup("timeout").DefValue = "900"
+	out := fs.String("out", "debug.tgz", "File to write the debug archive to")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: truenas_go debug [--out file] [flags]")
+		fs.PrintDefaults()
+	}
+	if len(parseFlags(fs, args)) != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	client := conn.connect()
+	defer client.Close()
+	ctx, cancel := conn.context()
+	defer cancel()
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", *out, err)
+	}
+	defer f.Close()
+	size, err := truenas_api.NewSystem(client).Debug(ctx, f, func(progress float64, state string, desc string) {
+		fmt.Fprintf(os.Stderr, "%5.1f%% %s\n", progress, desc)
+	})
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		os.Remove(*out)
+		log.Fatalf("Failed to collect the debug archive: %v", err)
+	}
+
+	// Check that the archive made it to disk completely
+	info, err := f.Stat()
+	if err != nil {
+		log.Fatalf("Failed to check %s: %v", *out, err)
+	}
+	if info.Size() != size {
+		log.Fatalf("%s has %d bytes, expected %d", *out, info.Size(), size)
+	}
+	fmt.Printf("Wrote %s (%d bytes)\n", *out, size)
+}
+
 func main() {
+	// Subcommands, e.g. "truenas_go service status"
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
+
 	// Define command-line flags
 	serverURL := flag.String("uri", "", "WebSocket server URI (e.g., ws://localhost:6000/websocket)")
 	method := flag.String("method", "", "RPC method to call (e.g., core.ping)")
@@ -42,6 +435,7 @@ func main() {
 	user := flag.String("U", "", "Username for login")
 	pass := flag.String("P", "", "Password for login")
 	apiKey := flag.String("api-key", "", "API key for login")
+	validate := flag.Bool("validate", false, "Validate params against the method schema before sending")
 
 	// Parse the flags
 	flag.Parse()
@@ -60,7 +454,11 @@ func main() {
 	}
 
 	// Create a new WebSocket client
-	client, err := truenas_api.NewClient(*serverURL, *verifySSL)
+	var opts []truenas_api.ClientOption
+	if *validate {
+		opts = append(opts, truenas_api.WithValidation())
+	}
+	client, err := truenas_api.NewClient(*serverURL, *verifySSL, opts...)
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
@@ -69,7 +467,9 @@ func main() {
 	// Attempt to log in if credentials or API key are provided
 	if *apiKey != "" || (*user != "" && *pass != "") {
 		err = client.Login(*user, *pass, *apiKey)
-		if err != nil {
+		if errors.Is(err, truenas_api.ErrSchemasUnavailable) {
+			log.Printf("Warning: %v, sending calls unvalidated", err)
+		} else if err != nil {
 			log.Fatalf("Login failed: %v", err)
 		}
 		//fmt.Println("Successfully logged in.")